@@ -0,0 +1,426 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IgnoreDriftAnnotation is the annotation on an object owned by a
+// CharacterCounter listing the comma separated fields the operator does not
+// revert when they are changed by hand, e.g. "replicas,image". A field also
+// covers the fields below it, e.g. "livenessProbe".
+const IgnoreDriftAnnotation = "ramp-up.joe.ionos.io/ignore-drift"
+
+// PausedAnnotation is the annotation pausing the reconciliation of a
+// CharacterCounter when set to "true", equivalent to spec.paused.
+const PausedAnnotation = "ramp-up.joe.ionos.io/paused"
+
+// CharacterCounterSpec defines the desired state of CharacterCounter
+// +kubebuilder:validation:XValidation:rule="!has(self.http) || !self.http.enabled || !has(self.port) || self.http.port != self.port",message="http.port must differ from port"
+// +kubebuilder:validation:XValidation:rule="!has(self.tls) || !has(self.port) || self.tls.healthPort != self.port",message="tls.healthPort must differ from port"
+// +kubebuilder:validation:XValidation:rule="!has(self.tls) || !has(self.http) || !self.http.enabled || self.tls.healthPort != self.http.port",message="tls.healthPort must differ from http.port"
+// +kubebuilder:validation:XValidation:rule="!has(self.monitoring) || !has(self.port) || self.monitoring.port != self.port",message="monitoring.port must differ from port"
+// +kubebuilder:validation:XValidation:rule="!has(self.monitoring) || !has(self.http) || !self.http.enabled || self.monitoring.port != self.http.port",message="monitoring.port must differ from http.port"
+// +kubebuilder:validation:XValidation:rule="!has(self.monitoring) || !has(self.tls) || self.monitoring.port != self.tls.healthPort",message="monitoring.port must differ from tls.healthPort"
+type CharacterCounterSpec struct {
+	// Port is the port the character counter server listens on.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=50051
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Replicas is the number of desired server pods. If unset, the operator
+	// leaves the replicas of the Deployment to others, e.g. a
+	// HorizontalPodAutoscaler, and a new Deployment starts with one pod.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// ResponseValue is the value returned by the server with every response.
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	ResponseValue string `json:"responseValue,omitempty"`
+
+	// Image is the container image of the character counter server.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// ImagePullPolicy is the pull policy of the server image.
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// +kubebuilder:default=IfNotPresent
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Service configures the Service exposing the gRPC port.
+	// +kubebuilder:default={}
+	// +optional
+	Service ServiceSpec `json:"service,omitempty"`
+
+	// HTTP configures the HTTP/JSON gateway of the server.
+	// +kubebuilder:default={}
+	// +optional
+	HTTP HTTPSpec `json:"http,omitempty"`
+
+	// Limits restricts the size of the requests the server accepts.
+	// +kubebuilder:default={}
+	// +optional
+	Limits LimitsSpec `json:"limits,omitempty"`
+
+	// RateLimit limits the request rate of every client of the server.
+	// Requests are not rate limited if unset.
+	// +optional
+	RateLimit *RateLimitSpec `json:"rateLimit,omitempty"`
+
+	// TLS serves the gRPC endpoint and the HTTP gateway over TLS. They are
+	// served in plaintext if unset.
+	// +optional
+	TLS *TLSSpec `json:"tls,omitempty"`
+
+	// Auth requires clients to authenticate with a bearer token. All clients
+	// are accepted if unset.
+	// +optional
+	Auth *AuthSpec `json:"auth,omitempty"`
+
+	// Monitoring configures the Prometheus metrics of the server.
+	// +kubebuilder:default={}
+	// +optional
+	Monitoring MonitoringSpec `json:"monitoring,omitempty"`
+
+	// RolloutOnConfigChange restarts the server pods when the server
+	// configuration changes. If false, the running servers reload the changed
+	// configuration without a restart. Changed ports always roll the pods.
+	// +kubebuilder:default=true
+	// +optional
+	RolloutOnConfigChange *bool `json:"rolloutOnConfigChange,omitempty"`
+
+	// DeletionPolicy decides whether the dependent objects are deleted
+	// together with the CharacterCounter or retained for later adoption.
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Paused stops the operator from changing the owned objects. The status
+	// is still updated.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// DeletionPolicy describes what happens to dependent objects when a
+// CharacterCounter is deleted.
+// +kubebuilder:validation:Enum=Delete;Retain
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete scales the server to zero, waits for its pods to
+	// terminate, at most for the drain timeout of the operator, and deletes
+	// all dependent objects.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain keeps all dependent objects and removes the owner
+	// references pointing to the deleted CharacterCounter.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// ServiceType describes how the character counter server is exposed.
+// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer;Headless
+type ServiceType string
+
+const (
+	// ServiceTypeClusterIP exposes the server on a cluster-internal IP.
+	ServiceTypeClusterIP ServiceType = "ClusterIP"
+	// ServiceTypeNodePort exposes the server on a port of every node.
+	ServiceTypeNodePort ServiceType = "NodePort"
+	// ServiceTypeLoadBalancer exposes the server through a cloud load balancer.
+	ServiceTypeLoadBalancer ServiceType = "LoadBalancer"
+	// ServiceTypeHeadless creates a Service without a cluster IP, so gRPC
+	// clients can resolve all pod IPs and balance the load themselves.
+	ServiceTypeHeadless ServiceType = "Headless"
+)
+
+// ServiceSpec configures the Service exposing the character counter server.
+type ServiceSpec struct {
+	// Type is the type of the Service.
+	// +kubebuilder:default=ClusterIP
+	// +optional
+	Type ServiceType `json:"type,omitempty"`
+}
+
+// HTTPSpec configures the HTTP/JSON gateway serving the counter RPCs to
+// clients that cannot speak gRPC, e.g. POST /v1/count.
+type HTTPSpec struct {
+	// Enabled starts the gateway and exposes its port on the Service.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Port is the port the gateway listens on. It must differ from the gRPC port.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=8080
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// LimitsSpec restricts the size of the requests the server accepts. Requests
+// exceeding a limit fail with RESOURCE_EXHAUSTED.
+type LimitsSpec struct {
+	// MaxTextBytes is the maximum size of a text sent in a single message.
+	// Texts beyond gRPC's message size limit of 4 MiB must be streamed.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1048576
+	// +optional
+	MaxTextBytes int32 `json:"maxTextBytes,omitempty"`
+
+	// MaxStreamBytes is the maximum size of a text sent as stream of chunks.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1073741824
+	// +optional
+	MaxStreamBytes int64 `json:"maxStreamBytes,omitempty"`
+
+	// MaxBatchItems is the maximum number of texts in a batch.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1000
+	// +optional
+	MaxBatchItems int32 `json:"maxBatchItems,omitempty"`
+}
+
+// RateLimitKey describes what clients are told apart by when rate limiting.
+// +kubebuilder:validation:Enum=Peer;APIKey
+type RateLimitKey string
+
+const (
+	// RateLimitKeyPeer limits every client address.
+	RateLimitKeyPeer RateLimitKey = "Peer"
+	// RateLimitKeyAPIKey limits every API key sent in the request metadata
+	// and its client address, or every auth key if authentication is enabled.
+	// Clients sending no API key are limited by address.
+	RateLimitKeyAPIKey RateLimitKey = "APIKey"
+)
+
+// MonitoringSpec configures the Prometheus metrics of the server.
+type MonitoringSpec struct {
+	// Enabled exposes the metrics port on the Service and creates a
+	// ServiceMonitor of the same name scraping it. It requires the CRDs of
+	// the Prometheus operator.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Port is the port the metrics are served on. It must differ from the
+	// other ports of the server.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=9090
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Interval is the scrape interval of the ServiceMonitor, e.g. 30s.
+	// Defaults to the scrape interval of Prometheus.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	Interval string `json:"interval,omitempty"`
+}
+
+// RateLimitSpec limits the request rate of clients with token buckets.
+// Requests finding their bucket empty fail with RESOURCE_EXHAUSTED and a
+// hint when to retry. Health checks are never limited.
+type RateLimitSpec struct {
+	// RequestsPerSecond is the rate the bucket of a client is refilled at.
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerSecond int32 `json:"requestsPerSecond"`
+
+	// Burst is the size of the bucket of a client. Defaults to RequestsPerSecond.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+
+	// Key is what clients are told apart by.
+	// +kubebuilder:default=Peer
+	// +optional
+	Key RateLimitKey `json:"key,omitempty"`
+
+	// APIKeyHeader is the gRPC metadata key or HTTP header holding the API key.
+	// +kubebuilder:default=x-api-key
+	// +optional
+	APIKeyHeader string `json:"apiKeyHeader,omitempty"`
+}
+
+// TLSMode describes where the serving certificate of a CharacterCounter comes from.
+// +kubebuilder:validation:Enum=Auto;Provided
+type TLSMode string
+
+const (
+	// TLSModeAuto generates a self-signed CA and a serving certificate for
+	// the Service DNS names and rotates them before they expire.
+	TLSModeAuto TLSMode = "Auto"
+	// TLSModeProvided uses the certificate of an existing Secret.
+	TLSModeProvided TLSMode = "Provided"
+)
+
+// TLSClientAuth describes whether the server verifies client certificates.
+// +kubebuilder:validation:Enum=None;Optional;Require
+type TLSClientAuth string
+
+const (
+	// TLSClientAuthNone does not request client certificates.
+	TLSClientAuthNone TLSClientAuth = "None"
+	// TLSClientAuthOptional verifies client certificates if clients send one.
+	TLSClientAuthOptional TLSClientAuth = "Optional"
+	// TLSClientAuthRequire rejects clients without a valid client certificate.
+	TLSClientAuthRequire TLSClientAuth = "Require"
+)
+
+// TLSSpec configures the certificates of the server. Certificates updated
+// in the Secrets are picked up by the running servers without a restart.
+// +kubebuilder:validation:XValidation:rule="self.mode != 'Provided' || has(self.secretRef)",message="secretRef is required in Provided mode"
+type TLSSpec struct {
+	// Mode decides where the serving certificate comes from. In Auto mode it
+	// is stored in the Secret <name>-tls, its CA in the Secret <name>-ca.
+	// +kubebuilder:default=Auto
+	// +optional
+	Mode TLSMode `json:"mode,omitempty"`
+
+	// SecretRef references the kubernetes.io/tls Secret holding the serving
+	// certificate in Provided mode.
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// ClientAuth decides whether the server verifies client certificates.
+	// +kubebuilder:default=None
+	// +optional
+	ClientAuth TLSClientAuth `json:"clientAuth,omitempty"`
+
+	// ClientCASecretRef references a Secret whose ca.crt holds the CAs client
+	// certificates are verified against. Defaults to the ca.crt of the
+	// serving certificate Secret, which in Auto mode is the generated CA.
+	// +optional
+	ClientCASecretRef *corev1.LocalObjectReference `json:"clientCASecretRef,omitempty"`
+
+	// HealthPort is the port the gRPC health service is served on without
+	// TLS, as kubelet gRPC probes cannot speak TLS. It is not exposed on the
+	// Service.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=8086
+	// +optional
+	HealthPort int32 `json:"healthPort,omitempty"`
+}
+
+// AuthSpec configures the keys clients authenticate with. Clients send a key
+// as "authorization: Bearer <key>" metadata. Health checks and reflection
+// stay open.
+type AuthSpec struct {
+	// SecretRef references the Secret holding the accepted keys, one per data
+	// entry named after the client owning it. Changed keys are reloaded by
+	// the running servers without restarting them.
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+}
+
+// Condition types of a CharacterCounter.
+const (
+	// ConditionAvailable is true when the server Deployment has the minimum
+	// number of available replicas.
+	ConditionAvailable = "Available"
+	// ConditionProgressing is true while the server Deployment rolls out.
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is true when reconciling failed or the rollout of
+	// the server Deployment is stuck.
+	ConditionDegraded = "Degraded"
+	// ConditionPaused is true while the reconciliation of the owned objects
+	// is paused through spec.paused or the paused annotation.
+	ConditionPaused = "Paused"
+)
+
+// CharacterCounterStatus defines the observed state of CharacterCounter
+type CharacterCounterStatus struct {
+	// ObservedGeneration is the generation of the spec the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Replicas is the number of non-terminated server pods.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ReadyReplicas is the number of server pods ready to serve requests.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// Endpoint is the address clients dial to reach the server.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// LastDriftCorrection describes the last time the operator reverted
+	// changes made to an owned object outside of the operator.
+	// +optional
+	LastDriftCorrection *DriftCorrection `json:"lastDriftCorrection,omitempty"`
+
+	// Conditions represent the latest available observations of the CharacterCounter.
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// DriftCorrection describes a revert of fields of an owned object.
+type DriftCorrection struct {
+	// Time is when the drift was corrected.
+	Time metav1.Time `json:"time"`
+
+	// Kind is the kind of the corrected object.
+	Kind string `json:"kind"`
+
+	// Name is the name of the corrected object.
+	Name string `json:"name"`
+
+	// Fields are the names of the reverted fields.
+	Fields []string `json:"fields"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+//+kubebuilder:printcolumn:name="Port",type=integer,JSONPath=`.spec.port`
+//+kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.spec.replicas`
+//+kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+//+kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+//+kubebuilder:printcolumn:name="Paused",type=string,JSONPath=`.status.conditions[?(@.type=="Paused")].status`,priority=1
+//+kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.endpoint`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CharacterCounter is the Schema for the charactercounters API
+type CharacterCounter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CharacterCounterSpec   `json:"spec,omitempty"`
+	Status CharacterCounterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CharacterCounterList contains a list of CharacterCounter
+type CharacterCounterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CharacterCounter `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CharacterCounter{}, &CharacterCounterList{})
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+// fieldManager is the field manager of all fields applied by the operator.
+const fieldManager = "charactercounter-operator"
+
+// applyConflictError is returned by apply when another field manager owns a
+// field the operator wants to set.
+type applyConflictError struct {
+	kind, name string
+	causes     []metav1.StatusCause
+}
+
+func (e *applyConflictError) Error() string {
+	msgs := make([]string, 0, len(e.causes))
+	for _, c := range e.causes {
+		msgs = append(msgs, c.Message)
+	}
+	return fmt.Sprintf("apply %s %s: %s", e.kind, e.name, strings.Join(msgs, "; "))
+}
+
+// apply sets cc as controller of the rendered obj and applies it with
+// server-side apply. Only the fields set on obj are owned by the operator,
+// so other controllers can manage the remaining ones. The returned result
+// reports whether the object was created or changed.
+//
+// If the live object drifted from obj, because fields checked by drift or
+// any other field set on obj were edited by hand, the apply forces the
+// ownership of these fields back to the operator and records the correction
+// on cc. Fields applied by other controllers are reported as
+// applyConflictError instead.
+func (r *CharacterCounterReconciler) apply(ctx context.Context, cc *rampupv1alpha1.CharacterCounter, obj client.Object,
+	drift driftFunc,
+) (controllerutil.OperationResult, error) {
+	if err := controllerutil.SetControllerReference(cc, obj, r.Scheme); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	kind := r.kindOf(obj)
+
+	live := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		// Unstructured objects are only read with their kind set.
+		live.GetObjectKind().SetGroupVersionKind(u.GroupVersionKind())
+	}
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), live)
+	if client.IgnoreNotFound(err) != nil {
+		return controllerutil.OperationResultNone, fmt.Errorf("get %s %s: %w", kind, obj.GetName(), err)
+	}
+	created := apierrors.IsNotFound(err)
+
+	opts := []client.PatchOption{client.FieldOwner(fieldManager)}
+	var drifted []string
+	ignored := ignoredDriftFields(live)
+	if !created {
+		drifted = drift(obj, live, ignored)
+	}
+
+	err = r.Patch(ctx, obj, client.Apply, opts...)
+	if causes, ok := conflictCauses(err); ok && !created {
+		// Any other rendered field edited by hand conflicts too. They are
+		// taken back as well, unless they are ignored or were applied by
+		// another controller.
+		var edited []managedField
+		var claimed bool
+		if edited, claimed, err = r.handEditedFields(ctx, obj, live); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		if claimed {
+			return controllerutil.OperationResultNone, &applyConflictError{kind: kind, name: obj.GetName(), causes: causes}
+		}
+		if drifted, err = revertHandEdits(obj, edited, drifted, ignored); err != nil {
+			return controllerutil.OperationResultNone, fmt.Errorf("keep ignored fields of %s %s: %w", kind, obj.GetName(), err)
+		}
+		opts = append(opts, client.ForceOwnership)
+		err = r.Patch(ctx, obj, client.Apply, opts...)
+	}
+	if err != nil {
+		if causes, ok := conflictCauses(err); ok {
+			return controllerutil.OperationResultNone, &applyConflictError{kind: kind, name: obj.GetName(), causes: causes}
+		}
+		return controllerutil.OperationResultNone, fmt.Errorf("apply %s %s: %w", kind, obj.GetName(), err)
+	}
+
+	if len(drifted) > 0 {
+		log.FromContext(ctx).Info("corrected drift", "kind", kind, "name", obj.GetName(), "fields", drifted)
+		r.Recorder.Eventf(cc, corev1.EventTypeWarning, eventReasonDriftCorrected,
+			"Reverted %s of %s %s", strings.Join(drifted, ","), kind, obj.GetName())
+		cc.Status.LastDriftCorrection = &rampupv1alpha1.DriftCorrection{
+			Time:   metav1.Now(),
+			Kind:   kind,
+			Name:   obj.GetName(),
+			Fields: drifted,
+		}
+	}
+
+	switch {
+	case created:
+		return controllerutil.OperationResultCreated, nil
+	case obj.GetResourceVersion() != live.GetResourceVersion():
+		return controllerutil.OperationResultUpdated, nil
+	default:
+		return controllerutil.OperationResultNone, nil
+	}
+}
+
+// handEditedFields returns the fields of obj a forced apply takes over from
+// other field managers, found by a dry run. claimed reports whether one of
+// them was applied by another field manager, like a controller, rather than
+// updated by hand.
+func (r *CharacterCounterReconciler) handEditedFields(ctx context.Context, obj, live client.Object) (
+	edited []managedField, claimed bool, err error,
+) {
+	dryRun := obj.DeepCopyObject().(client.Object)
+	if err := r.Patch(ctx, dryRun, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership, client.DryRunAll); err != nil {
+		return nil, false, fmt.Errorf("dry-run apply %s %s: %w", r.kindOf(obj), obj.GetName(), err)
+	}
+	for _, taken := range takenFields(live.GetManagedFields(), dryRun.GetManagedFields()) {
+		if taken.operation == metav1.ManagedFieldsOperationApply {
+			claimed = true
+		}
+		edited = append(edited, taken.field)
+	}
+	return edited, claimed, nil
+}
+
+// conflictCauses returns the field manager conflicts reported by err.
+func conflictCauses(err error) ([]metav1.StatusCause, bool) {
+	if !apierrors.IsConflict(err) {
+		return nil, false
+	}
+	var causes []metav1.StatusCause
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Details != nil {
+		for _, c := range status.Status().Details.Causes {
+			if c.Type == metav1.CauseTypeFieldManagerConflict {
+				causes = append(causes, c)
+			}
+		}
+	}
+	return causes, len(causes) > 0
+}
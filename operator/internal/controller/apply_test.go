@@ -0,0 +1,181 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+func TestConflictCauses(t *testing.T) {
+	conflict := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   http.StatusConflict,
+		Reason: metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "kubectl-edit" using apps/v1`,
+			Field:   ".spec.replicas",
+		}}},
+	}}
+
+	causes, ok := conflictCauses(fmt.Errorf("wrapped: %w", conflict))
+	if !ok || len(causes) != 1 || causes[0].Field != ".spec.replicas" {
+		t.Errorf("conflictCauses() = %v, %t, want the replicas conflict", causes, ok)
+	}
+
+	if _, ok := conflictCauses(errors.New("boom")); ok {
+		t.Error("conflictCauses() reported a conflict for a plain error")
+	}
+}
+
+var _ = Describe("Server-side apply of dependents", func() {
+	ctx := context.Background()
+	var (
+		r  *CharacterCounterReconciler
+		cc *rampupv1alpha1.CharacterCounter
+	)
+
+	BeforeEach(func() {
+		r, _ = newEnvtestReconciler()
+		cc = newTestCharacterCounter()
+		cc.Namespace = createTestNamespace()
+		Expect(k8sClient.Create(ctx, cc)).To(Succeed())
+	})
+
+	// applyDeployment applies the rendered Deployment of cc.
+	applyDeployment := func() (controllerutil.OperationResult, error) {
+		return r.apply(ctx, cc, deploymentForCharacterCounter(cc, "hash"), deploymentDrift)
+	}
+
+	// editLivenessPeriod sets the liveness probe period of the live
+	// Deployment like kubectl edit, annotating it with ignoreDrift if set.
+	editLivenessPeriod := func(period int32, ignoreDrift string) {
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), dep)).To(Succeed())
+		dep.Spec.Template.Spec.Containers[0].LivenessProbe.PeriodSeconds = period
+		if ignoreDrift != "" {
+			dep.Annotations = map[string]string{rampupv1alpha1.IgnoreDriftAnnotation: ignoreDrift}
+		}
+		Expect(k8sClient.Update(ctx, dep, client.FieldOwner("kubectl-edit"))).To(Succeed())
+	}
+
+	livenessPeriod := func() int32 {
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), dep)).To(Succeed())
+		return dep.Spec.Template.Spec.Containers[0].LivenessProbe.PeriodSeconds
+	}
+
+	It("applies the fields with the field manager of the operator", func() {
+		op, err := applyDeployment()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(op).To(Equal(controllerutil.OperationResultCreated))
+
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), dep)).To(Succeed())
+		Expect(dep.ManagedFields).To(ContainElement(And(
+			HaveField("Manager", fieldManager),
+			HaveField("Operation", metav1.ManagedFieldsOperationApply),
+		)))
+		Expect(metav1.IsControlledBy(dep, cc)).To(BeTrue())
+
+		op, err = applyDeployment()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(op).To(Equal(controllerutil.OperationResultNone))
+		Expect(cc.Status.LastDriftCorrection).To(BeNil())
+	})
+
+	It("reports fields applied by another field manager as conflict", func() {
+		cm := &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: cc.Name, Namespace: cc.Namespace},
+			Data:       map[string]string{configFileName: "{}"},
+		}
+		_, err := r.apply(ctx, cc, cm.DeepCopy(), configMapDrift)
+		Expect(err).NotTo(HaveOccurred())
+
+		other := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": cc.Name, "namespace": cc.Namespace},
+			"data":       map[string]interface{}{configFileName: `{"port": 1}`},
+		}}
+		Expect(k8sClient.Patch(ctx, other, client.Apply, client.FieldOwner("other-controller"), client.ForceOwnership)).To(Succeed())
+
+		_, err = r.apply(ctx, cc, cm.DeepCopy(), configMapDrift)
+		var conflictErr *applyConflictError
+		Expect(errors.As(err, &conflictErr)).To(BeTrue(), "error %v is no conflict", err)
+		Expect(conflictErr.kind).To(Equal("ConfigMap"))
+		Expect(conflictErr.causes).To(ContainElement(HaveField("Field", ".data."+configFileName)))
+		Expect(err.Error()).To(ContainSubstring("other-controller"))
+
+		live := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), live)).To(Succeed())
+		Expect(live.Data[configFileName]).To(Equal(`{"port": 1}`), "field of the other controller overridden")
+		Expect(cc.Status.LastDriftCorrection).To(BeNil())
+	})
+
+	It("forces back fields edited by hand", func() {
+		_, err := applyDeployment()
+		Expect(err).NotTo(HaveOccurred())
+		editLivenessPeriod(30, "")
+
+		op, err := applyDeployment()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(op).To(Equal(controllerutil.OperationResultUpdated))
+		Expect(livenessPeriod()).To(BeEquivalentTo(10))
+		Expect(cc.Status.LastDriftCorrection).NotTo(BeNil())
+		Expect(cc.Status.LastDriftCorrection.Fields).To(Equal([]string{
+			"spec.template.spec.containers[name=character-counter].livenessProbe.periodSeconds",
+		}))
+
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), dep)).To(Succeed())
+		for _, mf := range dep.ManagedFields {
+			if mf.Manager == "kubectl-edit" {
+				Expect(string(mf.FieldsV1.Raw)).NotTo(ContainSubstring("f:periodSeconds"))
+			}
+		}
+	})
+
+	It("keeps ignored fields edited by hand", func() {
+		_, err := applyDeployment()
+		Expect(err).NotTo(HaveOccurred())
+		editLivenessPeriod(30, "livenessProbe")
+
+		_, err = applyDeployment()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(livenessPeriod()).To(BeEquivalentTo(30))
+		Expect(cc.Status.LastDriftCorrection).To(BeNil())
+	})
+})
@@ -0,0 +1,99 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+const (
+	// authVolumeName is the name of the volume holding the auth keys.
+	authVolumeName = "auth"
+	// authMountPath is the directory the auth keys are mounted to.
+	authMountPath = "/etc/character-counter/auth"
+)
+
+// reconcileAuth checks that the auth Secret of cc exists. Its keys are not
+// hashed into the pod template, as the servers reload the mounted keys
+// without a restart.
+func (r *CharacterCounterReconciler) reconcileAuth(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) error {
+	if cc.Spec.Auth == nil {
+		return nil
+	}
+	secret := &corev1.Secret{}
+	name := cc.Spec.Auth.SecretRef.Name
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: cc.Namespace}, secret); err != nil {
+		return fmt.Errorf("get auth secret %s: %w", name, err)
+	}
+	log.FromContext(ctx).V(1).Info("read auth keys", "secret", name, "keys", len(secret.Data))
+	return nil
+}
+
+// authVolume returns the volume holding the auth keys of cc.
+func authVolume(cc *rampupv1alpha1.CharacterCounter) corev1.Volume {
+	return corev1.Volume{
+		Name: authVolumeName,
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+			SecretName: cc.Spec.Auth.SecretRef.Name,
+		}},
+	}
+}
+
+// referencedSecretNames returns the names of the Secrets cc uses without
+// owning them.
+func referencedSecretNames(cc *rampupv1alpha1.CharacterCounter) []string {
+	names := tlsReferencedSecretNames(cc)
+	if cc.Spec.Auth != nil {
+		names = append(names, cc.Spec.Auth.SecretRef.Name)
+	}
+	return names
+}
+
+// secretRefIndex is the field index of CharacterCounters by the names of the
+// Secrets they reference.
+const secretRefIndex = "spec.secretRefs"
+
+// indexSecretRefs is the indexer function of secretRefIndex.
+func indexSecretRefs(obj client.Object) []string {
+	return referencedSecretNames(obj.(*rampupv1alpha1.CharacterCounter))
+}
+
+// characterCountersForSecret maps a Secret to the CharacterCounters in its
+// namespace referencing it, so changed keys and certificates are applied.
+// They are looked up in the secretRefIndex, so changes of unrelated Secrets
+// are cheap.
+func (r *CharacterCounterReconciler) characterCountersForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	list := &rampupv1alpha1.CharacterCounterList{}
+	if err := r.List(ctx, list, client.InNamespace(secret.GetNamespace()),
+		client.MatchingFields{secretRefIndex: secret.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list charactercounters for secret", "secret", secret.GetName())
+		return nil
+	}
+	reqs := make([]reconcile.Request, 0, len(list.Items))
+	for i := range list.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+	}
+	return reqs
+}
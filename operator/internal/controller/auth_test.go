@@ -0,0 +1,112 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+func TestReferencedSecretNames(t *testing.T) {
+	cc := newTestCharacterCounter()
+	if names := referencedSecretNames(cc); len(names) != 0 {
+		t.Errorf("referencedSecretNames() = %v without TLS and auth, want none", names)
+	}
+
+	// Generated certificates are owned, not referenced.
+	cc.Spec.TLS = &rampupv1alpha1.TLSSpec{Mode: rampupv1alpha1.TLSModeAuto}
+	cc.Spec.Auth = &rampupv1alpha1.AuthSpec{SecretRef: corev1.LocalObjectReference{Name: "keys"}}
+	if names, want := referencedSecretNames(cc), []string{"keys"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("referencedSecretNames() = %v, want %v", names, want)
+	}
+
+	cc.Spec.TLS = &rampupv1alpha1.TLSSpec{
+		Mode:              rampupv1alpha1.TLSModeProvided,
+		SecretRef:         &corev1.LocalObjectReference{Name: "server-cert"},
+		ClientAuth:        rampupv1alpha1.TLSClientAuthRequire,
+		ClientCASecretRef: &corev1.LocalObjectReference{Name: "client-ca"},
+	}
+	if names, want := referencedSecretNames(cc), []string{"server-cert", "client-ca", "keys"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("referencedSecretNames() = %v, want %v", names, want)
+	}
+}
+
+func TestCharacterCountersForSecret(t *testing.T) {
+	withAuth := func(name, namespace, secret string) *rampupv1alpha1.CharacterCounter {
+		cc := newTestCharacterCounter()
+		cc.Name, cc.Namespace = name, namespace
+		cc.Spec.Auth = &rampupv1alpha1.AuthSpec{SecretRef: corev1.LocalObjectReference{Name: secret}}
+		return cc
+	}
+	s := runtime.NewScheme()
+	if err := rampupv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithIndex(&rampupv1alpha1.CharacterCounter{}, secretRefIndex, indexSecretRefs).
+		WithObjects(
+			withAuth("a", "default", "keys"),
+			withAuth("b", "default", "other-keys"),
+			withAuth("c", "other", "keys"),
+			newTestCharacterCounter(),
+		).Build()
+	r := &CharacterCounterReconciler{Client: c}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "default"}}
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "a", Namespace: "default"}}}
+	if got := r.characterCountersForSecret(context.Background(), secret); !reflect.DeepEqual(got, want) {
+		t.Errorf("characterCountersForSecret() = %v, want %v", got, want)
+	}
+}
+
+func TestAuthDeploymentAndConfig(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.Auth = &rampupv1alpha1.AuthSpec{SecretRef: corev1.LocalObjectReference{Name: "keys"}}
+
+	dep := deploymentForCharacterCounter(cc, "hash-1")
+	c := dep.Spec.Template.Spec.Containers[0]
+	if m := c.VolumeMounts[len(c.VolumeMounts)-1]; m.Name != authVolumeName || m.MountPath != authMountPath || !m.ReadOnly {
+		t.Errorf("unexpected auth volume mount %+v", m)
+	}
+	volumes := dep.Spec.Template.Spec.Volumes
+	if v := volumes[len(volumes)-1]; v.Secret == nil || v.Secret.SecretName != "keys" {
+		t.Errorf("unexpected auth volume %+v", v)
+	}
+
+	data, err := configMapData(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg serverConfig
+	if err := json.Unmarshal([]byte(data[configFileName]), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth == nil || cfg.Auth.KeysDir != authMountPath {
+		t.Errorf("auth config = %+v, want keys dir %s", cfg.Auth, authMountPath)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// caValidity is the lifetime of a generated CA.
+	caValidity = 365 * 24 * time.Hour
+	// servingCertValidity is the lifetime of a generated serving certificate.
+	servingCertValidity = 90 * 24 * time.Hour
+	// certBackdate is how far the validity of generated certificates starts
+	// in the past, to tolerate clock skew between nodes.
+	certBackdate = 5 * time.Minute
+)
+
+// keyPair is a certificate with its private key, both PEM encoded.
+type keyPair struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+
+	certPEM, keyPEM []byte
+}
+
+// renewAt returns the time kp is replaced, once two thirds of its lifetime passed.
+func (kp *keyPair) renewAt() time.Time {
+	lifetime := kp.cert.NotAfter.Sub(kp.cert.NotBefore)
+	return kp.cert.NotAfter.Add(-lifetime / 3)
+}
+
+// parseKeyPair parses the PEM encoded certificate and private key.
+func parseKeyPair(certPEM, keyPEM []byte) (*keyPair, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot sign")
+	}
+	return &keyPair{cert: cert, key: key, certPEM: certPEM, keyPEM: keyPEM}, nil
+}
+
+// newCA generates a self-signed CA for the CharacterCounter name, valid from now.
+func newCA(name string, now time.Time) (*keyPair, error) {
+	tmpl := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name + "-ca"},
+		NotBefore:             now.Add(-certBackdate),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	return newKeyPair(tmpl, nil)
+}
+
+// newServingCert generates a serving certificate for dnsNames signed by ca,
+// valid from now.
+func newServingCert(ca *keyPair, dnsNames []string, now time.Time) (*keyPair, error) {
+	tmpl := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:    dnsNames,
+		NotBefore:   now.Add(-certBackdate),
+		NotAfter:    now.Add(servingCertValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	return newKeyPair(tmpl, ca)
+}
+
+// newKeyPair generates a key and a certificate from tmpl signed by parent,
+// or self-signed if parent is nil.
+func newKeyPair(tmpl *x509.Certificate, parent *keyPair) (*keyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	if tmpl.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return nil, fmt.Errorf("generate serial number: %w", err)
+	}
+
+	signer, signerKey := tmpl, crypto.Signer(key)
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, key.Public(), signerKey)
+	if err != nil {
+		return nil, fmt.Errorf("create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("marshal key: %w", err)
+	}
+	return &keyPair{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// validServingCert reports whether kp is a serving certificate for exactly
+// dnsNames, signed by ca and not due for renewal at now.
+func validServingCert(kp, ca *keyPair, dnsNames []string, now time.Time) bool {
+	if now.After(kp.renewAt()) || kp.cert.CheckSignatureFrom(ca.cert) != nil || len(kp.cert.DNSNames) != len(dnsNames) {
+		return false
+	}
+	for i, name := range dnsNames {
+		if kp.cert.DNSNames[i] != name {
+			return false
+		}
+	}
+	return true
+}
+
+// caBundle returns the PEM encoded certificates of ca followed by those of
+// previous that are still valid at now, so clients trusting a rotated CA
+// keep working until they reload the bundle.
+func caBundle(ca *keyPair, previous []byte, now time.Time) []byte {
+	bundle := append([]byte{}, ca.certPEM...)
+	for {
+		var block *pem.Block
+		if block, previous = pem.Decode(previous); block == nil {
+			return bundle
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil || cert.Equal(ca.cert) || now.After(cert.NotAfter) {
+			continue
+		}
+		bundle = append(bundle, pem.EncodeToMemory(block)...)
+	}
+}
@@ -18,10 +18,20 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
@@ -30,33 +40,164 @@ import (
 // CharacterCounterReconciler reconciles a CharacterCounter object
 type CharacterCounterReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// DrainTimeout is the time the teardown of a deleted CharacterCounter
+	// waits for its server pods to terminate before deleting its dependents,
+	// DefaultDrainTimeout if zero.
+	DrainTimeout time.Duration
+
+	// watchesServiceMonitors reports whether ServiceMonitors are watched,
+	// which requires their CRD when the controller is set up.
+	watchesServiceMonitors bool
 }
 
 //+kubebuilder:rbac:groups=ramp-up.joe.ionos.io,resources=charactercounters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ramp-up.joe.ionos.io,resources=charactercounters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ramp-up.joe.ionos.io,resources=charactercounters/finalizers,verbs=update
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=deployments/scale,verbs=update
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the CharacterCounter object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// It renders the TLS Secrets, the ConfigMap configuring the character counter
+// server, the Deployment running it and the Service exposing it from the
+// CharacterCounter spec, creates or updates them in the cluster and reports
+// their state in the status. Changes of referenced Secrets, like the auth
+// keys, are applied as well. Generated certificates are renewed by requeueing
+// before they expire. Deleted CharacterCounters are torn down according to
+// their deletion policy before the finalizer is removed. Paused
+// CharacterCounters only get their status updated, but are still torn down on
+// deletion.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.15.0/pkg/reconcile
 func (r *CharacterCounterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx)
+
+	cc := &rampupv1alpha1.CharacterCounter{}
+	if err := r.Get(ctx, req.NamespacedName, cc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !cc.DeletionTimestamp.IsZero() {
+		res, err := r.finalize(ctx, cc)
+		if err != nil {
+			r.Recorder.Event(cc, corev1.EventTypeWarning, eventReasonReconcileFailed, err.Error())
+		}
+		return res, err
+	}
 
-	// TODO(user): your logic here
+	if controllerutil.AddFinalizer(cc, characterCounterFinalizer) {
+		if err := r.Update(ctx, cc); err != nil {
+			logger.Error(err, "unable to add finalizer")
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(cc, corev1.EventTypeNormal, eventReasonFinalizerAdded, "Added finalizer %s", characterCounterFinalizer)
+	}
 
-	return ctrl.Result{}, nil
+	var (
+		res ctrl.Result
+		err error
+	)
+	if isPaused(cc) {
+		logger.Info("reconciliation is paused")
+	} else {
+		res, err = r.reconcileDependents(ctx, cc)
+	}
+	var conflictErr *applyConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		r.Recorder.Event(cc, corev1.EventTypeWarning, eventReasonFieldConflict, err.Error())
+	case err != nil:
+		r.Recorder.Event(cc, corev1.EventTypeWarning, eventReasonReconcileFailed, err.Error())
+	}
+	if statusErr := r.updateStatus(ctx, cc, err); statusErr != nil {
+		logger.Error(statusErr, "unable to update status")
+		if err == nil {
+			err = statusErr
+		}
+	}
+	return res, err
+}
+
+// reconcileDependents creates or updates all objects derived from the spec of
+// cc. The result requeues cc when its generated certificates are renewed.
+func (r *CharacterCounterReconciler) reconcileDependents(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	renewAt, err := r.reconcileTLS(ctx, cc)
+	if err != nil {
+		logger.Error(err, "unable to reconcile TLS secrets")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileAuth(ctx, cc); err != nil {
+		logger.Error(err, "unable to reconcile auth keys")
+		return ctrl.Result{}, err
+	}
+
+	configHash, err := r.reconcileConfigMap(ctx, cc)
+	if err != nil {
+		logger.Error(err, "unable to reconcile config map")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileDeployment(ctx, cc, configHash); err != nil {
+		logger.Error(err, "unable to reconcile deployment")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileService(ctx, cc); err != nil {
+		logger.Error(err, "unable to reconcile service")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileServiceMonitor(ctx, cc); err != nil {
+		logger.Error(err, "unable to reconcile service monitor")
+		return ctrl.Result{}, err
+	}
+
+	if renewAt.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: time.Until(renewAt)}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CharacterCounterReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &rampupv1alpha1.CharacterCounter{}, secretRefIndex,
+		indexSecretRefs)
+	if err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&rampupv1alpha1.CharacterCounter{}).
-		Complete(r)
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Secret{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.characterCountersForSecret))
+
+	// ServiceMonitors can only be watched if the CRDs of the Prometheus
+	// operator are installed.
+	_, err = mgr.GetRESTMapper().RESTMapping(serviceMonitorGVK.GroupKind(), serviceMonitorGVK.Version)
+	switch {
+	case err == nil:
+		sm := &unstructured.Unstructured{}
+		sm.SetGroupVersionKind(serviceMonitorGVK)
+		b = b.Owns(sm)
+		r.watchesServiceMonitors = true
+	case !apimeta.IsNoMatchError(err):
+		return fmt.Errorf("look up ServiceMonitor kind: %w", err)
+	}
+	return b.Complete(r)
 }
@@ -0,0 +1,118 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+var _ = Describe("Events of a reconciliation", func() {
+	ctx := context.Background()
+	var (
+		r        *CharacterCounterReconciler
+		recorder *record.FakeRecorder
+		cc       *rampupv1alpha1.CharacterCounter
+	)
+
+	BeforeEach(func() {
+		r, recorder = newEnvtestReconciler()
+		cc = newTestCharacterCounter()
+		cc.Namespace = createTestNamespace()
+		Expect(k8sClient.Create(ctx, cc)).To(Succeed())
+		_, err := reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("records created and updated dependents", func() {
+		Expect(recordedEvents(recorder)).To(ContainElements(
+			"Normal "+eventReasonFinalizerAdded+" Added finalizer "+characterCounterFinalizer,
+			"Normal "+eventReasonConfigMapCreated+" Created ConfigMap sample",
+			"Normal "+eventReasonDeploymentCreated+" Created Deployment sample",
+			"Normal "+eventReasonServiceCreated+" Created Service sample",
+		))
+
+		_, err := reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recordedEvents(recorder)).To(BeEmpty(), "events recorded for unchanged dependents")
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), cc)).To(Succeed())
+		cc.Spec.ResponseValue = "changed"
+		cc.Spec.Replicas = pointer.Int32(3)
+		Expect(k8sClient.Update(ctx, cc)).To(Succeed())
+		_, err = reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+		events := recordedEvents(recorder)
+		Expect(events).To(ContainElements(
+			"Normal "+eventReasonConfigMapUpdated+" Updated ConfigMap sample",
+			"Normal "+eventReasonDeploymentUpdated+" Updated Deployment sample",
+		))
+		Expect(events).NotTo(ContainElement(HavePrefix("Normal " + eventReasonServiceUpdated)))
+	})
+
+	It("records corrected drift", func() {
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), dep)).To(Succeed())
+		dep.Spec.Template.Spec.Containers[0].Image = "debug:latest"
+		Expect(k8sClient.Update(ctx, dep, client.FieldOwner("kubectl-edit"))).To(Succeed())
+		recordedEvents(recorder)
+
+		_, err := reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recordedEvents(recorder)).To(ContainElement(
+			"Warning " + eventReasonDriftCorrected + " Reverted image of Deployment sample",
+		))
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), dep)).To(Succeed())
+		Expect(dep.Spec.Template.Spec.Containers[0].Image).To(Equal(cc.Spec.Image))
+	})
+
+	It("records field conflicts with other controllers", func() {
+		other := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": cc.Name, "namespace": cc.Namespace},
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": containerName, "image": "other:latest"}},
+			}}},
+		}}
+		Expect(k8sClient.Patch(ctx, other, client.Apply, client.FieldOwner("other-controller"), client.ForceOwnership)).To(Succeed())
+		recordedEvents(recorder)
+
+		_, err := reconcileCharacterCounter(r, cc)
+		var conflictErr *applyConflictError
+		Expect(errors.As(err, &conflictErr)).To(BeTrue(), "error %v is no conflict", err)
+		Expect(recordedEvents(recorder)).To(ContainElement(HavePrefix("Warning " + eventReasonFieldConflict + " apply Deployment sample")))
+
+		live := &rampupv1alpha1.CharacterCounter{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), live)).To(Succeed())
+		degraded := apimeta.FindStatusCondition(live.Status.Conditions, rampupv1alpha1.ConditionDegraded)
+		Expect(degraded).NotTo(BeNil())
+		Expect(degraded.Reason).To(Equal(reasonFieldConflict))
+	})
+})
@@ -0,0 +1,194 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+// configFileName is the key of the server configuration in the ConfigMap.
+const configFileName = "config.json"
+
+// serverConfig is the configuration file read by the character counter server.
+type serverConfig struct {
+	Port           int32  `json:"port"`
+	HTTPPort       int32  `json:"httpPort,omitempty"`
+	MetricsPort    int32  `json:"metricsPort,omitempty"`
+	ResponseValue  string `json:"responseValue"`
+	MaxTextBytes   int32  `json:"maxTextBytes,omitempty"`
+	MaxStreamBytes int64  `json:"maxStreamBytes,omitempty"`
+	MaxBatchItems  int32  `json:"maxBatchItems,omitempty"`
+
+	RateLimit *serverRateLimit `json:"rateLimit,omitempty"`
+
+	HealthPort int32       `json:"healthPort,omitempty"`
+	TLS        *serverTLS  `json:"tls,omitempty"`
+	Auth       *serverAuth `json:"auth,omitempty"`
+}
+
+// serverAuth is the auth section of serverConfig.
+type serverAuth struct {
+	KeysDir string `json:"keysDir"`
+}
+
+// serverTLS is the TLS section of serverConfig.
+type serverTLS struct {
+	CertFile     string `json:"certFile"`
+	KeyFile      string `json:"keyFile"`
+	ClientCAFile string `json:"clientCAFile,omitempty"`
+	ClientAuth   string `json:"clientAuth"`
+}
+
+// serverClientAuth maps the client certificate policies of the API to the ones of the server.
+var serverClientAuth = map[rampupv1alpha1.TLSClientAuth]string{
+	rampupv1alpha1.TLSClientAuthNone:     "none",
+	rampupv1alpha1.TLSClientAuthOptional: "optional",
+	rampupv1alpha1.TLSClientAuthRequire:  "require",
+}
+
+// serverRateLimit is the rate limit section of serverConfig.
+type serverRateLimit struct {
+	RequestsPerSecond int32  `json:"requestsPerSecond"`
+	Burst             int32  `json:"burst"`
+	Key               string `json:"key"`
+	APIKeyHeader      string `json:"apiKeyHeader,omitempty"`
+}
+
+// serverRateLimitKeys maps the rate limit keys of the API to the ones of the server.
+var serverRateLimitKeys = map[rampupv1alpha1.RateLimitKey]string{
+	rampupv1alpha1.RateLimitKeyPeer:   "peer",
+	rampupv1alpha1.RateLimitKeyAPIKey: "apiKey",
+}
+
+// serverConfigForCharacterCounter renders the server configuration of cc.
+func serverConfigForCharacterCounter(cc *rampupv1alpha1.CharacterCounter) serverConfig {
+	cfg := serverConfig{
+		Port:           cc.Spec.Port,
+		HTTPPort:       httpPort(cc),
+		MetricsPort:    metricsPort(cc),
+		ResponseValue:  cc.Spec.ResponseValue,
+		MaxTextBytes:   cc.Spec.Limits.MaxTextBytes,
+		MaxStreamBytes: cc.Spec.Limits.MaxStreamBytes,
+		MaxBatchItems:  cc.Spec.Limits.MaxBatchItems,
+	}
+	if rl := cc.Spec.RateLimit; rl != nil {
+		cfg.RateLimit = &serverRateLimit{
+			RequestsPerSecond: rl.RequestsPerSecond,
+			Burst:             rl.Burst,
+			Key:               serverRateLimitKeys[rl.Key],
+			APIKeyHeader:      rl.APIKeyHeader,
+		}
+		if cfg.RateLimit.Burst == 0 {
+			cfg.RateLimit.Burst = rl.RequestsPerSecond
+		}
+		if cfg.RateLimit.Key == "" {
+			cfg.RateLimit.Key = serverRateLimitKeys[rampupv1alpha1.RateLimitKeyPeer]
+		}
+	}
+	if tlsEnabled(cc) {
+		cfg.HealthPort = healthPort(cc)
+		cfg.TLS = &serverTLS{
+			CertFile:   path.Join(tlsMountPath, corev1.TLSCertKey),
+			KeyFile:    path.Join(tlsMountPath, corev1.TLSPrivateKeyKey),
+			ClientAuth: serverClientAuth[clientAuth(cc)],
+		}
+		if clientAuth(cc) != rampupv1alpha1.TLSClientAuthNone {
+			cfg.TLS.ClientCAFile = path.Join(tlsMountPath, clientCAFileName)
+		}
+	}
+	if cc.Spec.Auth != nil {
+		cfg.Auth = &serverAuth{KeysDir: authMountPath}
+	}
+	return cfg
+}
+
+// configMapData renders the data of the ConfigMap configuring the server of cc.
+func configMapData(cc *rampupv1alpha1.CharacterCounter) (map[string]string, error) {
+	b, err := json.MarshalIndent(serverConfigForCharacterCounter(cc), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal server config: %w", err)
+	}
+	return map[string]string{configFileName: string(b)}, nil
+}
+
+// restartHash returns the hash of the settings the server only applies on a
+// restart, its ports and TLS settings. Unlike the config hash it is always
+// set on the pod template, so they are enforced even if the servers reload
+// their configuration.
+func restartHash(cc *rampupv1alpha1.CharacterCounter) string {
+	cfg := serverConfigForCharacterCounter(cc)
+	restartOnly := struct {
+		Port, HTTPPort, MetricsPort, HealthPort int32
+		TLS                                     *serverTLS
+	}{cfg.Port, cfg.HTTPPort, cfg.MetricsPort, cfg.HealthPort, cfg.TLS}
+	// Marshaling plain values cannot fail.
+	b, _ := json.Marshal(restartOnly)
+	return hashConfigMapData(map[string]string{configFileName: string(b)})
+}
+
+// hashConfigMapData returns a stable hash of the given ConfigMap data.
+func hashConfigMapData(data map[string]string) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s\x00%s\x00", k, data[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reconcileConfigMap applies the ConfigMap configuring the server and
+// returns the hash of its data.
+func (r *CharacterCounterReconciler) reconcileConfigMap(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) (string, error) {
+	data, err := configMapData(cc)
+	if err != nil {
+		return "", err
+	}
+
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cc.Name,
+			Namespace: cc.Namespace,
+			Labels:    labelsForCharacterCounter(cc),
+		},
+		Data: data,
+	}
+	op, err := r.apply(ctx, cc, cm, configMapDrift)
+	if err != nil {
+		return "", err
+	}
+	log.FromContext(ctx).Info("reconciled config map", "configMap", cm.Name, "operation", op)
+	r.recordOperation(cc, op, eventReasonConfigMapCreated, eventReasonConfigMapUpdated, "ConfigMap", cm.Name)
+	return hashConfigMapData(data), nil
+}
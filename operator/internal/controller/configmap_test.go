@@ -0,0 +1,102 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+func TestConfigMapData(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.Limits.MaxTextBytes = 1024
+	data, err := configMapData(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg serverConfig
+	if err := json.Unmarshal([]byte(data[configFileName]), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != cc.Spec.Port || cfg.ResponseValue != cc.Spec.ResponseValue || cfg.MaxTextBytes != 1024 {
+		t.Errorf("unexpected server config %+v", cfg)
+	}
+
+	hash := hashConfigMapData(data)
+	again, _ := configMapData(cc)
+	if got := hashConfigMapData(again); got != hash {
+		t.Errorf("hash of unchanged config = %q, want %q", got, hash)
+	}
+
+	cc.Spec.ResponseValue = "changed"
+	changed, _ := configMapData(cc)
+	if hashConfigMapData(changed) == hash {
+		t.Error("hash did not change with the response value")
+	}
+}
+
+func TestConfigMapDataRateLimit(t *testing.T) {
+	cc := newTestCharacterCounter()
+	parse := func() serverConfig {
+		t.Helper()
+		data, err := configMapData(cc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cfg serverConfig
+		if err := json.Unmarshal([]byte(data[configFileName]), &cfg); err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	if cfg := parse(); cfg.RateLimit != nil {
+		t.Errorf("rate limit without spec.rateLimit = %+v, want none", cfg.RateLimit)
+	}
+
+	cc.Spec.RateLimit = &rampupv1alpha1.RateLimitSpec{RequestsPerSecond: 10, Key: rampupv1alpha1.RateLimitKeyAPIKey, APIKeyHeader: "x-token"}
+	want := serverRateLimit{RequestsPerSecond: 10, Burst: 10, Key: "apiKey", APIKeyHeader: "x-token"}
+	if cfg := parse(); cfg.RateLimit == nil || *cfg.RateLimit != want {
+		t.Errorf("rate limit = %+v, want %+v", cfg.RateLimit, want)
+	}
+}
+
+func TestConfigMapDataTLS(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.TLS = &rampupv1alpha1.TLSSpec{Mode: rampupv1alpha1.TLSModeAuto, ClientAuth: rampupv1alpha1.TLSClientAuthOptional}
+	data, err := configMapData(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg serverConfig
+	if err := json.Unmarshal([]byte(data[configFileName]), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := serverTLS{
+		CertFile:     "/etc/character-counter/tls/tls.crt",
+		KeyFile:      "/etc/character-counter/tls/tls.key",
+		ClientCAFile: "/etc/character-counter/tls/client-ca.crt",
+		ClientAuth:   "optional",
+	}
+	if cfg.TLS == nil || *cfg.TLS != want || cfg.HealthPort != defaultHealthPort {
+		t.Errorf("TLS = %+v with health port %d, want %+v with %d", cfg.TLS, cfg.HealthPort, want, defaultHealthPort)
+	}
+}
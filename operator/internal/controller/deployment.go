@@ -0,0 +1,233 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+const (
+	// containerName is the name of the server container in the pod template.
+	containerName = "character-counter"
+	// grpcPortName is the name of the gRPC port on the container and the Service.
+	grpcPortName = "grpc"
+	// grpcServiceName is the gRPC service the server reports the health of.
+	// The readiness probe checks it, the liveness probe the server as a whole.
+	grpcServiceName = "frontend.CharacterCounter"
+	// httpPortName is the name of the HTTP gateway port on the container and the Service.
+	httpPortName = "http"
+	// metricsPortName is the name of the metrics port on the container and the Service.
+	metricsPortName = "metrics"
+	// defaultMetricsPort is the metrics port of the server, unless set in the spec.
+	defaultMetricsPort = 9090
+	// defaultHealthPort is the plaintext health port of TLS servers, unless
+	// set in the spec.
+	defaultHealthPort = 8086
+
+	// configVolumeName is the name of the volume holding the server ConfigMap.
+	configVolumeName = "config"
+	// configMountPath is the directory the server ConfigMap is mounted to.
+	configMountPath = "/etc/character-counter"
+	// configHashAnnotation is the pod template annotation holding the hash of
+	// the server configuration. Changing it rolls the Deployment. It is only
+	// set if the CharacterCounter rolls out on config changes.
+	configHashAnnotation = "ramp-up.joe.ionos.io/config-hash"
+	// restartHashAnnotation is the pod template annotation holding the hash
+	// of the settings the server only applies on a restart. It is always set,
+	// so changing them rolls the Deployment.
+	restartHashAnnotation = "ramp-up.joe.ionos.io/restart-hash"
+)
+
+// labelsForCharacterCounter returns the labels set on all objects belonging
+// to the given CharacterCounter. They are also used as pod selector.
+func labelsForCharacterCounter(cc *rampupv1alpha1.CharacterCounter) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       "charactercounter",
+		"app.kubernetes.io/instance":   cc.Name,
+		"app.kubernetes.io/managed-by": "charactercounter-operator",
+	}
+}
+
+// reconcileDeployment applies the Deployment running the server.
+// configHash is the hash of the server configuration the pods are rolled on,
+// unless the servers reload their configuration instead.
+func (r *CharacterCounterReconciler) reconcileDeployment(ctx context.Context, cc *rampupv1alpha1.CharacterCounter, configHash string) error {
+	dep := deploymentForCharacterCounter(cc, configHash)
+	op, err := r.apply(ctx, cc, dep, deploymentDrift)
+	if err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("reconciled deployment", "deployment", dep.Name, "operation", op)
+	r.recordOperation(cc, op, eventReasonDeploymentCreated, eventReasonDeploymentUpdated, "Deployment", dep.Name)
+	return nil
+}
+
+// deploymentForCharacterCounter renders the Deployment running the server of
+// cc. It only contains the fields managed by the operator.
+func deploymentForCharacterCounter(cc *rampupv1alpha1.CharacterCounter, configHash string) *appsv1.Deployment {
+	labels := labelsForCharacterCounter(cc)
+
+	annotations := map[string]string{restartHashAnnotation: restartHash(cc)}
+	if rolloutOnConfigChange(cc) {
+		annotations[configHashAnnotation] = configHash
+	}
+
+	ports := []corev1.ContainerPort{{
+		Name:          grpcPortName,
+		ContainerPort: cc.Spec.Port,
+		Protocol:      corev1.ProtocolTCP,
+	}}
+	if port := httpPort(cc); port != 0 {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          httpPortName,
+			ContainerPort: port,
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+	if cc.Spec.Monitoring.Enabled {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          metricsPortName,
+			ContainerPort: metricsPort(cc),
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+
+	// Kubelet gRPC probes cannot speak TLS, so they check the plaintext
+	// health port of TLS servers.
+	probePort := cc.Spec.Port
+	volumes := []corev1.Volume{{
+		Name: configVolumeName,
+		VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: cc.Name},
+		}},
+	}}
+	mounts := []corev1.VolumeMount{{
+		Name:      configVolumeName,
+		MountPath: configMountPath,
+		ReadOnly:  true,
+	}}
+	if tlsEnabled(cc) {
+		probePort = healthPort(cc)
+		ports = append(ports, corev1.ContainerPort{
+			Name:          healthPortName,
+			ContainerPort: probePort,
+			Protocol:      corev1.ProtocolTCP,
+		})
+		volumes = append(volumes, tlsVolume(cc))
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      tlsVolumeName,
+			MountPath: tlsMountPath,
+			ReadOnly:  true,
+		})
+	}
+	if cc.Spec.Auth != nil {
+		volumes = append(volumes, authVolume(cc))
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      authVolumeName,
+			MountPath: authMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cc.Name,
+			Namespace: cc.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			// Left out if unset, so the operator does not own the field
+			// and an autoscaler can manage it.
+			Replicas: cc.Spec.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            containerName,
+						Image:           cc.Spec.Image,
+						ImagePullPolicy: cc.Spec.ImagePullPolicy,
+						Args:            []string{"--config=" + configMountPath + "/" + configFileName},
+						Ports:           ports,
+						LivenessProbe: &corev1.Probe{
+							ProbeHandler:     corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: probePort}},
+							PeriodSeconds:    10,
+							FailureThreshold: 3,
+						},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{
+								Port:    probePort,
+								Service: pointer.String(grpcServiceName),
+							}},
+							PeriodSeconds:    5,
+							FailureThreshold: 1,
+						},
+						VolumeMounts: mounts,
+					}},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+}
+
+// rolloutOnConfigChange reports whether the pods of cc are restarted when
+// the server configuration changes, which is the default.
+func rolloutOnConfigChange(cc *rampupv1alpha1.CharacterCounter) bool {
+	return cc.Spec.RolloutOnConfigChange == nil || *cc.Spec.RolloutOnConfigChange
+}
+
+// httpPort returns the port of the HTTP gateway of cc, or 0 if it is disabled.
+func httpPort(cc *rampupv1alpha1.CharacterCounter) int32 {
+	if !cc.Spec.HTTP.Enabled {
+		return 0
+	}
+	return cc.Spec.HTTP.Port
+}
+
+// metricsPort returns the metrics port of cc. Metrics are served even if
+// monitoring is disabled, but the port is only exposed if it is enabled.
+func metricsPort(cc *rampupv1alpha1.CharacterCounter) int32 {
+	if cc.Spec.Monitoring.Port == 0 {
+		return defaultMetricsPort
+	}
+	return cc.Spec.Monitoring.Port
+}
+
+// healthPort returns the plaintext health port of cc, or 0 if TLS is disabled.
+func healthPort(cc *rampupv1alpha1.CharacterCounter) int32 {
+	switch {
+	case !tlsEnabled(cc):
+		return 0
+	case cc.Spec.TLS.HealthPort == 0:
+		return defaultHealthPort
+	default:
+		return cc.Spec.TLS.HealthPort
+	}
+}
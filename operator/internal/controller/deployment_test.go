@@ -0,0 +1,163 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+func newTestCharacterCounter() *rampupv1alpha1.CharacterCounter {
+	return &rampupv1alpha1.CharacterCounter{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: rampupv1alpha1.CharacterCounterSpec{
+			Port:            50051,
+			Replicas:        pointer.Int32(2),
+			ResponseValue:   "hello",
+			Image:           "character-counter:latest",
+			ImagePullPolicy: corev1.PullIfNotPresent,
+		},
+	}
+}
+
+func TestDeploymentForCharacterCounter(t *testing.T) {
+	cc := newTestCharacterCounter()
+	dep := deploymentForCharacterCounter(cc, "hash-1")
+
+	if dep.APIVersion != "apps/v1" || dep.Kind != "Deployment" {
+		t.Errorf("type meta = %s/%s, want apps/v1/Deployment", dep.APIVersion, dep.Kind)
+	}
+	if got := *dep.Spec.Replicas; got != 2 {
+		t.Errorf("replicas = %d, want 2", got)
+	}
+	if got := dep.Spec.Selector.MatchLabels["app.kubernetes.io/instance"]; got != "sample" {
+		t.Errorf("selector instance label = %q, want %q", got, "sample")
+	}
+	if n := len(dep.Spec.Template.Spec.Containers); n != 1 {
+		t.Fatalf("got %d containers, want 1", n)
+	}
+	c := dep.Spec.Template.Spec.Containers[0]
+	if c.Image != cc.Spec.Image {
+		t.Errorf("image = %q, want %q", c.Image, cc.Spec.Image)
+	}
+	if c.Ports[0].ContainerPort != 50051 || c.Ports[0].Name != grpcPortName {
+		t.Errorf("unexpected container port %+v", c.Ports[0])
+	}
+	if p := c.LivenessProbe; p == nil || p.GRPC == nil || p.GRPC.Port != 50051 || p.GRPC.Service != nil {
+		t.Errorf("unexpected liveness probe %+v", p)
+	}
+	if p := c.ReadinessProbe; p == nil || p.GRPC == nil || p.GRPC.Port != 50051 ||
+		p.GRPC.Service == nil || *p.GRPC.Service != grpcServiceName {
+		t.Errorf("unexpected readiness probe %+v", p)
+	}
+	if got := dep.Spec.Template.Annotations[configHashAnnotation]; got != "hash-1" {
+		t.Errorf("config hash annotation = %q, want %q", got, "hash-1")
+	}
+	if v := dep.Spec.Template.Spec.Volumes[0]; v.ConfigMap == nil || v.ConfigMap.Name != cc.Name {
+		t.Errorf("unexpected config volume %+v", v)
+	}
+}
+
+func TestDeploymentForCharacterCounterHotReload(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.RolloutOnConfigChange = pointer.Bool(false)
+	dep := deploymentForCharacterCounter(cc, "hash-1")
+
+	if _, ok := dep.Spec.Template.Annotations[configHashAnnotation]; ok {
+		t.Error("config hash annotation set although the servers reload their config")
+	}
+}
+
+func TestDeploymentForCharacterCounterAutoscaled(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.Replicas = nil
+	b, err := json.Marshal(deploymentForCharacterCounter(cc, "hash-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), `"replicas"`) {
+		t.Errorf("applied Deployment sets replicas without spec.replicas: %s", b)
+	}
+}
+
+func TestDeploymentForCharacterCounterRestartHash(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.RolloutOnConfigChange = pointer.Bool(false)
+	cc.Spec.TLS = &rampupv1alpha1.TLSSpec{Mode: rampupv1alpha1.TLSModeAuto, ClientAuth: rampupv1alpha1.TLSClientAuthOptional}
+	hash := func() string {
+		return deploymentForCharacterCounter(cc, "hash-1").Spec.Template.Annotations[restartHashAnnotation]
+	}
+	initial := hash()
+
+	cc.Spec.ResponseValue = "reloaded"
+	if hash() != initial {
+		t.Error("restart hash changed with the reloadable response value")
+	}
+
+	// Changes the server ignores until a restart must roll the pods.
+	for name, change := range map[string]func(){
+		"clientAuth":  func() { cc.Spec.TLS.ClientAuth = rampupv1alpha1.TLSClientAuthRequire },
+		"port":        func() { cc.Spec.Port = 50052 },
+		"metricsPort": func() { cc.Spec.Monitoring.Port = 9191 },
+	} {
+		before := hash()
+		change()
+		if hash() == before {
+			t.Errorf("restart hash did not change with %s", name)
+		}
+	}
+}
+
+func TestDeploymentForCharacterCounterTLS(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.TLS = &rampupv1alpha1.TLSSpec{
+		Mode:              rampupv1alpha1.TLSModeProvided,
+		SecretRef:         &corev1.LocalObjectReference{Name: "server-cert"},
+		ClientAuth:        rampupv1alpha1.TLSClientAuthRequire,
+		ClientCASecretRef: &corev1.LocalObjectReference{Name: "client-ca"},
+		HealthPort:        8086,
+	}
+	dep := deploymentForCharacterCounter(cc, "hash-1")
+	c := dep.Spec.Template.Spec.Containers[0]
+
+	if p := c.Ports[len(c.Ports)-1]; p.Name != healthPortName || p.ContainerPort != 8086 {
+		t.Errorf("unexpected health port %+v", p)
+	}
+	if p := c.ReadinessProbe; p.GRPC.Port != 8086 || c.LivenessProbe.GRPC.Port != 8086 {
+		t.Errorf("probes check port %d and %d, want the health port 8086", p.GRPC.Port, c.LivenessProbe.GRPC.Port)
+	}
+	if m := c.VolumeMounts[1]; m.Name != tlsVolumeName || m.MountPath != tlsMountPath {
+		t.Errorf("unexpected TLS volume mount %+v", m)
+	}
+	v := dep.Spec.Template.Spec.Volumes[1]
+	if v.Projected == nil || len(v.Projected.Sources) != 2 {
+		t.Fatalf("unexpected TLS volume %+v", v)
+	}
+	if s := v.Projected.Sources[0].Secret; s.Name != "server-cert" {
+		t.Errorf("serving certificate projected from Secret %q, want %q", s.Name, "server-cert")
+	}
+	if s := v.Projected.Sources[1].Secret; s.Name != "client-ca" || s.Items[0].Path != clientCAFileName {
+		t.Errorf("unexpected client CA projection %+v", s)
+	}
+}
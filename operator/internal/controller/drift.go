@@ -0,0 +1,423 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+// driftFunc compares the rendered object desired with the live object and
+// returns the names of the fields that drifted. Drifted fields listed in
+// ignored are copied from live into desired instead, so the operator keeps
+// the value set by hand.
+type driftFunc func(desired, live client.Object, ignored sets.Set[string]) []string
+
+// driftChecker collects the drifted fields of a single live object.
+type driftChecker struct {
+	live    client.Object
+	ignored sets.Set[string]
+	drifted []string
+}
+
+// check compares a field of the rendered and the live object. A field that
+// differs only drifted if another field manager owns it at path, otherwise
+// the operator changed it itself, e.g. after an update of the spec. Drifted
+// fields are recorded, unless they are ignored and reset to their live value
+// by keepLive.
+func (d *driftChecker) check(field string, equal bool, keepLive func(), path ...string) {
+	switch {
+	case equal || !ownedByOthers(d.live, path):
+	case d.ignored.Has(field):
+		keepLive()
+	default:
+		d.drifted = append(d.drifted, field)
+	}
+}
+
+// ownedByOthers reports whether a field manager other than the operator owns
+// the field at path of obj. path consists of the keys of the managed fields,
+// e.g. "f:spec", "f:replicas".
+func ownedByOthers(obj client.Object, path []string) bool {
+	for _, mf := range obj.GetManagedFields() {
+		if mf.Manager == fieldManager || mf.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if hasFieldPath(fields, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFieldPath reports whether the managed fields contain path.
+func hasFieldPath(fields map[string]interface{}, path []string) bool {
+	for _, p := range path {
+		next, ok := fields[p].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		fields = next
+	}
+	return true
+}
+
+// managedField is the path of a single field in the managed fields of an
+// object, e.g. "f:spec", "f:template", "f:spec", "f:containers",
+// `k:{"name":"character-counter"}`, "f:env".
+type managedField []string
+
+// driftFieldAliases are the names of the fields with keys too long to list in
+// the ignore-drift annotation.
+var driftFieldAliases = map[string]string{
+	"f:" + configHashAnnotation:  "configHash",
+	"f:" + restartHashAnnotation: "restartHash",
+}
+
+// names returns the names of the fields on the path of f, which are matched
+// against the fields checked by name and the ignore-drift annotation.
+func (f managedField) names() []string {
+	var names []string
+	for _, seg := range f {
+		if alias, ok := driftFieldAliases[seg]; ok {
+			names = append(names, alias)
+		} else if strings.HasPrefix(seg, "f:") {
+			names = append(names, seg[2:])
+		}
+	}
+	return names
+}
+
+// String returns the readable path of f, e.g.
+// "spec.template.spec.containers[name=character-counter].env".
+func (f managedField) String() string {
+	var b strings.Builder
+	for _, seg := range f {
+		switch {
+		case strings.HasPrefix(seg, "f:"):
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(seg[2:])
+		case strings.HasPrefix(seg, "k:"):
+			var keys map[string]interface{}
+			_ = json.Unmarshal([]byte(seg[2:]), &keys)
+			pairs := make([]string, 0, len(keys))
+			for k, v := range keys {
+				pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+			}
+			sort.Strings(pairs)
+			fmt.Fprintf(&b, "[%s]", strings.Join(pairs, ","))
+		case strings.HasPrefix(seg, "v:"), strings.HasPrefix(seg, "i:"):
+			fmt.Fprintf(&b, "[%s]", seg[2:])
+		}
+	}
+	return b.String()
+}
+
+// managedFieldPaths returns the paths of the fields managed by mf, keyed by
+// their joined segments.
+func managedFieldPaths(mf metav1.ManagedFieldsEntry) map[string]managedField {
+	paths := map[string]managedField{}
+	var fields map[string]interface{}
+	if mf.FieldsV1 == nil || json.Unmarshal(mf.FieldsV1.Raw, &fields) != nil {
+		return paths
+	}
+	collectFieldPaths(fields, nil, paths)
+	return paths
+}
+
+// collectFieldPaths adds the paths of the leaves of fields below prefix to paths.
+func collectFieldPaths(fields map[string]interface{}, prefix managedField, paths map[string]managedField) {
+	for k, v := range fields {
+		path := append(prefix[:len(prefix):len(prefix)], k)
+		if children, _ := v.(map[string]interface{}); len(children) > 0 {
+			collectFieldPaths(children, path, paths)
+		} else {
+			paths[strings.Join(path, "\x00")] = path
+		}
+	}
+}
+
+// takenField is a field another field manager lost to the operator.
+type takenField struct {
+	field     managedField
+	operation metav1.ManagedFieldsOperationType
+}
+
+// takenFields returns the fields managed by other field managers before an
+// apply of the operator, but no longer after it.
+func takenFields(before, after []metav1.ManagedFieldsEntry) []takenField {
+	var taken []takenField
+	for _, b := range before {
+		if b.Manager == fieldManager {
+			continue
+		}
+		fields := managedFieldPaths(b)
+		for _, a := range after {
+			if a.Manager == b.Manager && a.Operation == b.Operation && a.APIVersion == b.APIVersion &&
+				a.Subresource == b.Subresource {
+				for key := range managedFieldPaths(a) {
+					delete(fields, key)
+				}
+			}
+		}
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			taken = append(taken, takenField{field: fields[key], operation: b.Operation})
+		}
+	}
+	return taken
+}
+
+// revertHandEdits handles the fields of obj edited by hand that are not
+// checked by a driftFunc. Ignored fields are removed from obj, so their
+// editor keeps them. The others are added to drifted, unless a field checked
+// by name already covers them, and reverted by the forced apply.
+func revertHandEdits(obj client.Object, edited []managedField, drifted []string, ignored sets.Set[string]) ([]string, error) {
+	covered := sets.New(drifted...)
+	var keep []managedField
+	for _, f := range edited {
+		switch names := f.names(); {
+		case ignored.HasAny(names...) || ignored.Has(f.String()):
+			keep = append(keep, f)
+		case !covered.HasAny(names...):
+			drifted = append(drifted, f.String())
+		}
+	}
+	if len(keep) == 0 {
+		return drifted, nil
+	}
+	return drifted, removeFields(obj, keep)
+}
+
+// removeFields removes the fields at paths from obj.
+func removeFields(obj client.Object, paths []managedField) error {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		for _, path := range paths {
+			removeFieldPath(u.Object, path)
+		}
+		return nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		removeFieldPath(content, path)
+	}
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(content, obj)
+}
+
+// removeFieldPath removes the field at path from the unstructured node and
+// returns the node, or nil if the node itself is removed.
+func removeFieldPath(node interface{}, path managedField) interface{} {
+	if len(path) == 0 || path[0] == "." {
+		return nil
+	}
+	seg, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		key := strings.TrimPrefix(seg, "f:")
+		if child, ok := n[key]; ok && key != seg {
+			if child = removeFieldPath(child, rest); child == nil {
+				delete(n, key)
+			} else {
+				n[key] = child
+			}
+		}
+	case []interface{}:
+		for i, item := range n {
+			if !matchesListItem(item, i, seg) {
+				continue
+			}
+			if item = removeFieldPath(item, rest); item == nil {
+				return append(n[:i:i], n[i+1:]...)
+			}
+			n[i] = item
+			break
+		}
+	}
+	return node
+}
+
+// matchesListItem reports whether the list item at index i is the one
+// identified by the path segment seg, by its keys, value or index.
+func matchesListItem(item interface{}, i int, seg string) bool {
+	switch {
+	case strings.HasPrefix(seg, "k:"):
+		var keys map[string]interface{}
+		fields, ok := item.(map[string]interface{})
+		if !ok || json.Unmarshal([]byte(seg[2:]), &keys) != nil {
+			return false
+		}
+		for k, v := range keys {
+			if !jsonEqual(fields[k], v) {
+				return false
+			}
+		}
+		return true
+	case strings.HasPrefix(seg, "v:"):
+		var v interface{}
+		return json.Unmarshal([]byte(seg[2:]), &v) == nil && jsonEqual(item, v)
+	case strings.HasPrefix(seg, "i:"):
+		return seg[2:] == fmt.Sprint(i)
+	}
+	return false
+}
+
+// jsonEqual reports whether a and b have the same JSON encoding, so numbers
+// decoded from JSON equal the integers of converted objects.
+func jsonEqual(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// ignoredDriftFields returns the fields listed in the ignore-drift annotation of obj.
+func ignoredDriftFields(obj client.Object) sets.Set[string] {
+	ignored := sets.New[string]()
+	for _, f := range strings.Split(obj.GetAnnotations()[rampupv1alpha1.IgnoreDriftAnnotation], ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			ignored.Insert(f)
+		}
+	}
+	return ignored
+}
+
+// deploymentDrift is the driftFunc of the server Deployment. It checks the
+// fields replicas, configHash, restartHash, image, imagePullPolicy, args and
+// ports.
+func deploymentDrift(desiredObj, liveObj client.Object, ignored sets.Set[string]) []string {
+	desired, live := desiredObj.(*appsv1.Deployment), liveObj.(*appsv1.Deployment)
+	d := &driftChecker{live: live, ignored: ignored}
+
+	// Without spec.replicas the replicas are owned by an autoscaler or
+	// kubectl scale, so changing them is no drift.
+	if desired.Spec.Replicas != nil {
+		d.check("replicas", live.Spec.Replicas != nil && *live.Spec.Replicas == *desired.Spec.Replicas,
+			func() { desired.Spec.Replicas = live.Spec.Replicas },
+			"f:spec", "f:replicas")
+	}
+
+	dtpl, ltpl := &desired.Spec.Template, &live.Spec.Template
+	if _, ok := dtpl.Annotations[configHashAnnotation]; ok {
+		d.check("configHash", dtpl.Annotations[configHashAnnotation] == ltpl.Annotations[configHashAnnotation],
+			func() { dtpl.Annotations[configHashAnnotation] = ltpl.Annotations[configHashAnnotation] },
+			"f:spec", "f:template", "f:metadata", "f:annotations", "f:"+configHashAnnotation)
+	}
+	d.check("restartHash", dtpl.Annotations[restartHashAnnotation] == ltpl.Annotations[restartHashAnnotation],
+		func() { dtpl.Annotations[restartHashAnnotation] = ltpl.Annotations[restartHashAnnotation] },
+		"f:spec", "f:template", "f:metadata", "f:annotations", "f:"+restartHashAnnotation)
+
+	dc := &dtpl.Spec.Containers[0]
+	var lc *corev1.Container
+	for i := range ltpl.Spec.Containers {
+		if ltpl.Spec.Containers[i].Name == dc.Name {
+			lc = &ltpl.Spec.Containers[i]
+		}
+	}
+	if lc == nil {
+		// The operator never removes its own container.
+		d.drifted = append(d.drifted, "containers")
+		return d.drifted
+	}
+	containerPath := []string{"f:spec", "f:template", "f:spec", "f:containers", fmt.Sprintf(`k:{"name":%q}`, dc.Name)}
+	d.check("image", dc.Image == lc.Image, func() { dc.Image = lc.Image },
+		append(containerPath, "f:image")...)
+	d.check("imagePullPolicy", dc.ImagePullPolicy == lc.ImagePullPolicy, func() { dc.ImagePullPolicy = lc.ImagePullPolicy },
+		append(containerPath, "f:imagePullPolicy")...)
+	d.check("args", equality.Semantic.DeepEqual(dc.Args, lc.Args), func() { dc.Args = lc.Args },
+		append(containerPath, "f:args")...)
+	d.check("ports", equality.Semantic.DeepEqual(dc.Ports, lc.Ports), func() { dc.Ports = lc.Ports },
+		append(containerPath, "f:ports")...)
+
+	return d.drifted
+}
+
+// serviceDrift is the driftFunc of the server Service. It checks the fields
+// type, selector and ports.
+func serviceDrift(desiredObj, liveObj client.Object, ignored sets.Set[string]) []string {
+	desired, live := desiredObj.(*corev1.Service), liveObj.(*corev1.Service)
+	d := &driftChecker{live: live, ignored: ignored}
+
+	d.check("type", desired.Spec.Type == live.Spec.Type, func() { desired.Spec.Type = live.Spec.Type },
+		"f:spec", "f:type")
+	d.check("selector", equality.Semantic.DeepEqual(desired.Spec.Selector, live.Spec.Selector),
+		func() { desired.Spec.Selector = live.Spec.Selector },
+		"f:spec", "f:selector")
+
+	// Node ports are allocated by the API server and not rendered.
+	livePorts := make([]corev1.ServicePort, len(live.Spec.Ports))
+	for i, p := range live.Spec.Ports {
+		p.NodePort = 0
+		livePorts[i] = p
+	}
+	d.check("ports", equality.Semantic.DeepEqual(desired.Spec.Ports, livePorts),
+		func() { desired.Spec.Ports = livePorts },
+		"f:spec", "f:ports")
+
+	return d.drifted
+}
+
+// configMapDrift is the driftFunc of the server ConfigMap. It checks the data.
+func configMapDrift(desiredObj, liveObj client.Object, ignored sets.Set[string]) []string {
+	desired, live := desiredObj.(*corev1.ConfigMap), liveObj.(*corev1.ConfigMap)
+	d := &driftChecker{live: live, ignored: ignored}
+
+	d.check("data", equality.Semantic.DeepEqual(desired.Data, live.Data), func() { desired.Data = live.Data },
+		"f:data")
+
+	return d.drifted
+}
+
+// secretDrift is the driftFunc of the generated TLS Secrets. It checks the data.
+func secretDrift(desiredObj, liveObj client.Object, ignored sets.Set[string]) []string {
+	desired, live := desiredObj.(*corev1.Secret), liveObj.(*corev1.Secret)
+	d := &driftChecker{live: live, ignored: ignored}
+
+	d.check("data", equality.Semantic.DeepEqual(desired.Data, live.Data), func() { desired.Data = live.Data },
+		"f:data")
+
+	return d.drifted
+}
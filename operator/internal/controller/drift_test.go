@@ -0,0 +1,216 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+func TestDeploymentDrift(t *testing.T) {
+	editedByHand := []metav1.ManagedFieldsEntry{{
+		Manager:    "kubectl-edit",
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		FieldsType: "FieldsV1",
+		FieldsV1: &metav1.FieldsV1{Raw: []byte(
+			`{"f:spec":{"f:replicas":{},"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"character-counter\"}":{"f:image":{}}}}}}}`,
+		)},
+	}}
+
+	tests := []struct {
+		name         string
+		managed      []metav1.ManagedFieldsEntry
+		ignore       string
+		wantDrift    []string
+		wantReplicas int32
+	}{
+		{name: "spec change", wantReplicas: 2},
+		{name: "edited by hand", managed: editedByHand, wantDrift: []string{"replicas", "image"}, wantReplicas: 2},
+		{name: "ignored", managed: editedByHand, ignore: "replicas, image", wantReplicas: 5},
+		{name: "partially ignored", managed: editedByHand, ignore: "image", wantDrift: []string{"replicas"}, wantReplicas: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := newTestCharacterCounter()
+			desired := deploymentForCharacterCounter(cc, "hash")
+			live := desired.DeepCopy()
+			live.Spec.Replicas = pointer.Int32(5)
+			live.Spec.Template.Spec.Containers[0].Image = "debug:latest"
+			live.ManagedFields = tt.managed
+			if tt.ignore != "" {
+				live.Annotations = map[string]string{rampupv1alpha1.IgnoreDriftAnnotation: tt.ignore}
+			}
+
+			got := deploymentDrift(desired, live, ignoredDriftFields(live))
+			if !reflect.DeepEqual(got, tt.wantDrift) {
+				t.Errorf("drift = %v, want %v", got, tt.wantDrift)
+			}
+			if *desired.Spec.Replicas != tt.wantReplicas {
+				t.Errorf("desired replicas = %d, want %d", *desired.Spec.Replicas, tt.wantReplicas)
+			}
+		})
+	}
+}
+
+func TestDeploymentDriftAutoscaled(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.Replicas = nil
+	desired := deploymentForCharacterCounter(cc, "hash")
+	live := desired.DeepCopy()
+	live.Spec.Replicas = pointer.Int32(7)
+	live.ManagedFields = []metav1.ManagedFieldsEntry{{
+		Manager:     "kube-controller-manager",
+		Operation:   metav1.ManagedFieldsOperationUpdate,
+		Subresource: "scale",
+		FieldsType:  "FieldsV1",
+		FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+	}}
+
+	if got := deploymentDrift(desired, live, ignoredDriftFields(live)); len(got) != 0 {
+		t.Errorf("drift = %v for replicas scaled by an autoscaler, want none", got)
+	}
+	if desired.Spec.Replicas != nil {
+		t.Errorf("desired replicas = %d, want them left to the autoscaler", *desired.Spec.Replicas)
+	}
+}
+
+// containerField returns the managed field path below the server container.
+func containerField(path ...string) managedField {
+	return append(managedField{"f:spec", "f:template", "f:spec", "f:containers", `k:{"name":"character-counter"}`}, path...)
+}
+
+func TestTakenFields(t *testing.T) {
+	entry := func(manager string, op metav1.ManagedFieldsOperationType, fields string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager: manager, Operation: op, APIVersion: "apps/v1",
+			FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: []byte(fields)},
+		}
+	}
+	before := []metav1.ManagedFieldsEntry{
+		entry(fieldManager, metav1.ManagedFieldsOperationApply, `{"f:spec":{"f:replicas":{}}}`),
+		entry("kubectl-edit", metav1.ManagedFieldsOperationUpdate,
+			`{"f:metadata":{"f:labels":{"f:team":{}}},"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"character-counter\"}":{"f:livenessProbe":{"f:periodSeconds":{}}}}}}}}`),
+		entry("other-controller", metav1.ManagedFieldsOperationApply, `{"f:spec":{"f:minReadySeconds":{}}}`),
+	}
+	after := []metav1.ManagedFieldsEntry{
+		entry(fieldManager, metav1.ManagedFieldsOperationApply, `{"f:spec":{"f:replicas":{}}}`),
+		entry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:labels":{"f:team":{}}}}`),
+	}
+
+	got := takenFields(before, after)
+	want := []takenField{
+		{field: containerField("f:livenessProbe", "f:periodSeconds"), operation: metav1.ManagedFieldsOperationUpdate},
+		{field: managedField{"f:spec", "f:minReadySeconds"}, operation: metav1.ManagedFieldsOperationApply},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("taken fields = %v, want %v", got, want)
+	}
+}
+
+func TestManagedFieldString(t *testing.T) {
+	tests := []struct {
+		field managedField
+		want  string
+	}{
+		{field: containerField("f:env", `k:{"name":"DEBUG"}`, "f:value"),
+			want: "spec.template.spec.containers[name=character-counter].env[name=DEBUG].value"},
+		{field: containerField("f:ports", `k:{"containerPort":50051,"protocol":"TCP"}`, "."),
+			want: "spec.template.spec.containers[name=character-counter].ports[containerPort=50051,protocol=TCP]"},
+		{field: managedField{"f:spec", "f:template", "f:metadata", "f:annotations", "f:" + configHashAnnotation},
+			want: "spec.template.metadata.annotations." + configHashAnnotation},
+		{field: managedField{"f:metadata", "f:finalizers", `v:"example.com/hold"`}, want: `metadata.finalizers["example.com/hold"]`},
+	}
+	for _, tt := range tests {
+		if got := tt.field.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestRevertHandEdits(t *testing.T) {
+	edited := []managedField{
+		containerField("f:image"),
+		containerField("f:livenessProbe", "f:periodSeconds"),
+		containerField("f:readinessProbe", "f:failureThreshold"),
+		{"f:spec", "f:template", "f:metadata", "f:annotations", "f:" + restartHashAnnotation},
+	}
+	desired := deploymentForCharacterCounter(newTestCharacterCounter(), "hash")
+
+	drifted, err := revertHandEdits(desired, edited, []string{"image"}, sets.New("livenessProbe", "restartHash"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"image", "spec.template.spec.containers[name=character-counter].readinessProbe.failureThreshold"}
+	if !reflect.DeepEqual(drifted, want) {
+		t.Errorf("drift = %v, want %v", drifted, want)
+	}
+
+	c := desired.Spec.Template.Spec.Containers[0]
+	if c.LivenessProbe.PeriodSeconds != 0 {
+		t.Errorf("liveness period = %d, want it left to the editor", c.LivenessProbe.PeriodSeconds)
+	}
+	if c.LivenessProbe.GRPC == nil || c.Image == "" || c.ReadinessProbe.FailureThreshold != 1 {
+		t.Errorf("container = %+v, want the fields not ignored kept", c)
+	}
+	if _, ok := desired.Spec.Template.Annotations[restartHashAnnotation]; ok {
+		t.Errorf("restart hash still rendered although ignored")
+	}
+	if desired.Kind != "Deployment" || desired.Name == "" {
+		t.Errorf("type or object meta lost: %+v", desired.ObjectMeta)
+	}
+}
+
+func TestRemoveFieldPath(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": int64(50051), "protocol": "TCP", "name": "grpc"},
+				map[string]interface{}{"port": int64(8080), "protocol": "TCP", "name": "http"},
+			},
+			"finalizers": []interface{}{"a", "b"},
+		},
+	}
+	removeFieldPath(obj, managedField{"f:spec", "f:ports", `k:{"port":8080,"protocol":"TCP"}`, "f:name"})
+	removeFieldPath(obj, managedField{"f:spec", "f:ports", `k:{"port":50051,"protocol":"TCP"}`, "."})
+	removeFieldPath(obj, managedField{"f:spec", "f:finalizers", `v:"a"`})
+	removeFieldPath(obj, managedField{"f:spec", "f:missing", "f:field"})
+
+	want := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports":      []interface{}{map[string]interface{}{"port": int64(8080), "protocol": "TCP"}},
+			"finalizers": []interface{}{"b"},
+		},
+	}
+	if !reflect.DeepEqual(obj, want) {
+		t.Errorf("object = %v, want %v", obj, want)
+	}
+
+	u := &unstructured.Unstructured{Object: obj}
+	if err := removeFields(u, []managedField{{"f:spec", "f:ports"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := u.Object["spec"].(map[string]interface{})["ports"]; ok {
+		t.Errorf("ports not removed from unstructured object")
+	}
+}
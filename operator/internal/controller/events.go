@@ -0,0 +1,66 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+// Reasons of the Events recorded on a CharacterCounter.
+//
+// Identical Events are aggregated into a single Event with an increasing
+// count by the correlator of the manager's event broadcaster, so a failure
+// loop does not flood the API server.
+const (
+	eventReasonConfigMapCreated      = "ConfigMapCreated"
+	eventReasonConfigMapUpdated      = "ConfigMapUpdated"
+	eventReasonDeploymentCreated     = "DeploymentCreated"
+	eventReasonDeploymentUpdated     = "DeploymentUpdated"
+	eventReasonServiceCreated        = "ServiceCreated"
+	eventReasonServiceUpdated        = "ServiceUpdated"
+	eventReasonServiceRecreated      = "ServiceRecreated"
+	eventReasonSecretCreated         = "SecretCreated"
+	eventReasonSecretUpdated         = "SecretUpdated"
+	eventReasonServiceMonitorCreated = "ServiceMonitorCreated"
+	eventReasonServiceMonitorUpdated = "ServiceMonitorUpdated"
+	eventReasonFinalizerAdded        = "FinalizerAdded"
+	eventReasonFinalizerRemoved      = "FinalizerRemoved"
+	eventReasonScaledToZero          = "ScaledToZero"
+	eventReasonDrainTimedOut         = "DrainTimedOut"
+	eventReasonDependentDeleted      = "DependentDeleted"
+	eventReasonDependentOrphaned     = "DependentOrphaned"
+	eventReasonReconcileFailed       = "ReconcileFailed"
+	eventReasonFieldConflict         = "FieldConflict"
+	eventReasonDriftCorrected        = "DriftCorrected"
+)
+
+// recordOperation records an Event on cc if op changed a dependent. The
+// reasons are chosen from created and updated, no Event is recorded for
+// unchanged dependents.
+func (r *CharacterCounterReconciler) recordOperation(cc *rampupv1alpha1.CharacterCounter, op controllerutil.OperationResult,
+	created, updated, kind, name string,
+) {
+	switch op {
+	case controllerutil.OperationResultCreated:
+		r.Recorder.Eventf(cc, corev1.EventTypeNormal, created, "Created %s %s", kind, name)
+	case controllerutil.OperationResultUpdated:
+		r.Recorder.Eventf(cc, corev1.EventTypeNormal, updated, "Updated %s %s", kind, name)
+	}
+}
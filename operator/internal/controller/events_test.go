@@ -0,0 +1,62 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestRecordOperation(t *testing.T) {
+	tests := []struct {
+		op   controllerutil.OperationResult
+		want []string
+	}{
+		{op: controllerutil.OperationResultCreated, want: []string{"Normal DeploymentCreated Created Deployment sample"}},
+		{op: controllerutil.OperationResultUpdated, want: []string{"Normal DeploymentUpdated Updated Deployment sample"}},
+		{op: controllerutil.OperationResultNone},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.op), func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &CharacterCounterReconciler{Recorder: recorder}
+			r.recordOperation(newTestCharacterCounter(), tt.op, eventReasonDeploymentCreated, eventReasonDeploymentUpdated,
+				"Deployment", "sample")
+
+			if got := recordedEvents(recorder); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordedEvents returns the events recorded so far, formatted as
+// "<type> <reason> <message>".
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
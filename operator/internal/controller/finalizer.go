@@ -0,0 +1,225 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+const (
+	// characterCounterFinalizer blocks the deletion of a CharacterCounter
+	// until its dependent objects are torn down.
+	characterCounterFinalizer = "ramp-up.joe.ionos.io/finalizer"
+
+	// drainRequeueInterval is the interval in which the teardown checks
+	// whether all server pods are gone.
+	drainRequeueInterval = 2 * time.Second
+
+	// DefaultDrainTimeout is the time the teardown waits for the server pods
+	// to terminate, unless the reconciler sets another one.
+	DefaultDrainTimeout = 5 * time.Minute
+)
+
+// dependentsForCharacterCounter returns the objects created for cc in the
+// order they are torn down. Kinds whose CRDs are not installed are skipped.
+func dependentsForCharacterCounter(cc *rampupv1alpha1.CharacterCounter) []client.Object {
+	meta := metav1.ObjectMeta{Name: cc.Name, Namespace: cc.Namespace}
+	return []client.Object{
+		newServiceMonitor(cc),
+		&corev1.Service{ObjectMeta: *meta.DeepCopy()},
+		&appsv1.Deployment{ObjectMeta: *meta.DeepCopy()},
+		&corev1.ConfigMap{ObjectMeta: *meta.DeepCopy()},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: servingSecretName(cc), Namespace: cc.Namespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: caSecretName(cc), Namespace: cc.Namespace}},
+	}
+}
+
+// finalize tears down the dependents of a deleted CharacterCounter according
+// to its deletion policy and removes the finalizer afterwards.
+func (r *CharacterCounterReconciler) finalize(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(cc, characterCounterFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	switch cc.Spec.DeletionPolicy {
+	case rampupv1alpha1.DeletionPolicyRetain:
+		if err := r.orphanDependents(ctx, cc); err != nil {
+			logger.Error(err, "unable to orphan dependents")
+			return ctrl.Result{}, err
+		}
+	default:
+		drained, err := r.drain(ctx, cc)
+		if err != nil {
+			logger.Error(err, "unable to drain server pods")
+			return ctrl.Result{}, err
+		}
+		if !drained {
+			// Pods stuck terminating, e.g. on an unreachable node, must not
+			// block the deletion forever.
+			if time.Since(cc.DeletionTimestamp.Time) < r.drainTimeout() {
+				logger.Info("waiting for server pods to terminate")
+				return ctrl.Result{RequeueAfter: drainRequeueInterval}, nil
+			}
+			logger.Info("server pods did not terminate in time, deleting dependents", "timeout", r.drainTimeout())
+			r.Recorder.Eventf(cc, corev1.EventTypeWarning, eventReasonDrainTimedOut,
+				"Server pods did not terminate within %s", r.drainTimeout())
+		}
+		if err := r.deleteDependents(ctx, cc); err != nil {
+			logger.Error(err, "unable to delete dependents")
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(cc, characterCounterFinalizer)
+	if err := r.Update(ctx, cc); err != nil {
+		logger.Error(err, "unable to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	logger.Info("removed finalizer", "deletionPolicy", cc.Spec.DeletionPolicy)
+	r.Recorder.Eventf(cc, corev1.EventTypeNormal, eventReasonFinalizerRemoved,
+		"Removed finalizer %s with deletion policy %s", characterCounterFinalizer, cc.Spec.DeletionPolicy)
+	return ctrl.Result{}, nil
+}
+
+// drain scales the Deployment of cc to zero and reports whether all of its
+// pods are gone. It only sets the replicas through the scale subresource, so
+// it neither conflicts with other writers of the Deployment nor changes the
+// fields applied by the operator. An autoscaler scaling the Deployment up
+// again fights the drain until the drain timeout expires.
+func (r *CharacterCounterReconciler) drain(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) (bool, error) {
+	dep := &appsv1.Deployment{}
+	err := r.Get(ctx, client.ObjectKey{Name: cc.Name, Namespace: cc.Namespace}, dep)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return false, fmt.Errorf("get deployment %s: %w", cc.Name, err)
+	case dep.Spec.Replicas == nil || *dep.Spec.Replicas != 0:
+		scale := &autoscalingv1.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: dep.Name, Namespace: dep.Namespace},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: 0},
+		}
+		err := r.SubResource("scale").Update(ctx, dep, client.WithSubResourceBody(scale), client.FieldOwner(fieldManager))
+		if err != nil {
+			return false, fmt.Errorf("scale deployment %s to zero: %w", dep.Name, err)
+		}
+		r.Recorder.Eventf(cc, corev1.EventTypeNormal, eventReasonScaledToZero, "Scaled Deployment %s to zero", dep.Name)
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(cc.Namespace), client.MatchingLabels(labelsForCharacterCounter(cc))); err != nil {
+		return false, fmt.Errorf("list server pods: %w", err)
+	}
+	return len(pods.Items) == 0, nil
+}
+
+// drainTimeout returns the time the teardown waits for the server pods to terminate.
+func (r *CharacterCounterReconciler) drainTimeout() time.Duration {
+	if r.DrainTimeout > 0 {
+		return r.DrainTimeout
+	}
+	return DefaultDrainTimeout
+}
+
+// deleteDependents deletes all dependents of cc in teardown order. Objects
+// not controlled by cc, like a provided TLS Secret, are left alone.
+func (r *CharacterCounterReconciler) deleteDependents(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) error {
+	for _, obj := range dependentsForCharacterCounter(cc) {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return fmt.Errorf("get %s %s: %w", r.kindOf(obj), obj.GetName(), err)
+		}
+		if !metav1.IsControlledBy(obj, cc) {
+			continue
+		}
+		err := r.Delete(ctx, obj)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("delete %s %s: %w", r.kindOf(obj), obj.GetName(), err)
+		}
+		r.Recorder.Eventf(cc, corev1.EventTypeNormal, eventReasonDependentDeleted, "Deleted %s %s", r.kindOf(obj), obj.GetName())
+	}
+	return nil
+}
+
+// orphanDependents removes the owner references to cc from all of its
+// dependents, so they survive the deletion and can be adopted later.
+func (r *CharacterCounterReconciler) orphanDependents(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) error {
+	for _, obj := range dependentsForCharacterCounter(cc) {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return fmt.Errorf("get %s %s: %w", r.kindOf(obj), obj.GetName(), err)
+		}
+		if !removeOwnerReference(cc, obj) {
+			continue
+		}
+		if err := r.Update(ctx, obj, client.FieldOwner(fieldManager)); err != nil {
+			return fmt.Errorf("remove owner reference from %s %s: %w", r.kindOf(obj), obj.GetName(), err)
+		}
+		r.Recorder.Eventf(cc, corev1.EventTypeNormal, eventReasonDependentOrphaned, "Retained %s %s", r.kindOf(obj), obj.GetName())
+	}
+	return nil
+}
+
+// removeOwnerReference removes the owner reference to owner from obj and
+// reports whether obj was changed.
+func removeOwnerReference(owner, obj metav1.Object) bool {
+	refs := obj.GetOwnerReferences()
+	kept := make([]metav1.OwnerReference, 0, len(refs))
+	for _, ref := range refs {
+		if ref.UID != owner.GetUID() {
+			kept = append(kept, ref)
+		}
+	}
+	if len(kept) == len(refs) {
+		return false
+	}
+	obj.SetOwnerReferences(kept)
+	return true
+}
+
+// kindOf returns the kind of obj as registered in the scheme.
+func (r *CharacterCounterReconciler) kindOf(obj client.Object) string {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return fmt.Sprintf("%T", obj)
+	}
+	return gvk.Kind
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+var _ = Describe("Teardown of a deleted CharacterCounter", func() {
+	ctx := context.Background()
+	var (
+		r        *CharacterCounterReconciler
+		recorder *record.FakeRecorder
+		cc       *rampupv1alpha1.CharacterCounter
+	)
+
+	BeforeEach(func() {
+		r, recorder = newEnvtestReconciler()
+		cc = newTestCharacterCounter()
+		cc.Namespace = createTestNamespace()
+	})
+
+	// serverObjects returns the Deployment, Service and ConfigMap of cc.
+	serverObjects := func() []client.Object {
+		meta := metav1.ObjectMeta{Name: cc.Name, Namespace: cc.Namespace}
+		return []client.Object{
+			&appsv1.Deployment{ObjectMeta: *meta.DeepCopy()},
+			&corev1.Service{ObjectMeta: *meta.DeepCopy()},
+			&corev1.ConfigMap{ObjectMeta: *meta.DeepCopy()},
+		}
+	}
+
+	// createAndDelete creates cc, reconciles its dependents and deletes it.
+	createAndDelete := func() {
+		Expect(k8sClient.Create(ctx, cc)).To(Succeed())
+		_, err := reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+		for _, obj := range serverObjects() {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+			Expect(metav1.IsControlledBy(obj, cc)).To(BeTrue(), "%T not controlled by the CharacterCounter", obj)
+		}
+		Expect(k8sClient.Delete(ctx, cc)).To(Succeed())
+	}
+
+	expectGone := func(obj client.Object) {
+		err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue(), "%T %s still exists: %v", obj, obj.GetName(), err)
+	}
+
+	It("deletes the dependents with the Delete policy", func() {
+		createAndDelete()
+
+		res, err := reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		for _, obj := range serverObjects() {
+			expectGone(obj)
+		}
+		expectGone(&rampupv1alpha1.CharacterCounter{ObjectMeta: cc.ObjectMeta})
+		Expect(recordedEvents(recorder)).To(ContainElements(
+			HavePrefix("Normal "+eventReasonScaledToZero),
+			HavePrefix("Normal "+eventReasonDependentDeleted+" Deleted Deployment"),
+			HavePrefix("Normal "+eventReasonFinalizerRemoved),
+		))
+	})
+
+	It("retains the dependents without owner references with the Retain policy", func() {
+		cc.Spec.DeletionPolicy = rampupv1alpha1.DeletionPolicyRetain
+		createAndDelete()
+
+		_, err := reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+		for _, obj := range serverObjects() {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+			Expect(obj.GetOwnerReferences()).To(BeEmpty(), "%T still owned", obj)
+		}
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), dep)).To(Succeed())
+		Expect(*dep.Spec.Replicas).To(BeEquivalentTo(2), "retained Deployment scaled")
+		expectGone(&rampupv1alpha1.CharacterCounter{ObjectMeta: cc.ObjectMeta})
+		Expect(recordedEvents(recorder)).To(ContainElements(
+			HavePrefix("Normal "+eventReasonDependentOrphaned+" Retained Deployment"),
+			HavePrefix("Normal "+eventReasonFinalizerRemoved),
+		))
+	})
+
+	It("waits for the server pods to terminate until the drain timeout", func() {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: cc.Name + "-0", Namespace: cc.Namespace, Labels: labelsForCharacterCounter(cc)},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: containerName, Image: cc.Spec.Image}}},
+		}
+		Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		createAndDelete()
+
+		res, err := reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(drainRequeueInterval))
+		dep := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), dep)).To(Succeed())
+		Expect(*dep.Spec.Replicas).To(BeZero())
+		live := &rampupv1alpha1.CharacterCounter{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cc), live)).To(Succeed())
+		Expect(controllerutil.ContainsFinalizer(live, characterCounterFinalizer)).To(BeTrue())
+
+		r.DrainTimeout = time.Nanosecond
+		res, err = reconcileCharacterCounter(r, cc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		expectGone(dep)
+		expectGone(live)
+		Expect(recordedEvents(recorder)).To(ContainElement(HavePrefix("Warning " + eventReasonDrainTimedOut)))
+	})
+})
+
+// newEnvtestReconciler returns a reconciler talking to the test environment
+// and the recorder collecting its events.
+func newEnvtestReconciler() (*CharacterCounterReconciler, *record.FakeRecorder) {
+	recorder := record.NewFakeRecorder(100)
+	return &CharacterCounterReconciler{Client: k8sClient, Scheme: scheme.Scheme, Recorder: recorder}, recorder
+}
+
+// createTestNamespace creates a namespace for a single spec and returns its
+// name. Namespaces are never deleted in the test environment, so every spec
+// gets a new one.
+func createTestNamespace() string {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-"}}
+	Expect(k8sClient.Create(context.Background(), ns)).To(Succeed())
+	return ns.Name
+}
+
+// reconcileCharacterCounter runs a single reconciliation of cc.
+func reconcileCharacterCounter(r *CharacterCounterReconciler, cc client.Object) (ctrl.Result, error) {
+	return r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cc)})
+}
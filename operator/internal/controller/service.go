@@ -0,0 +1,143 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+const (
+	// grpcAppProtocol is the application protocol announced on the gRPC Service port.
+	grpcAppProtocol = "grpc"
+	// httpAppProtocol is the application protocol announced on the HTTP gateway
+	// and metrics Service ports.
+	httpAppProtocol = "http"
+	// httpsAppProtocol is the application protocol announced on the HTTP
+	// gateway Service port of TLS servers.
+	httpsAppProtocol = "https"
+)
+
+// reconcileService applies the Service exposing the server.
+func (r *CharacterCounterReconciler) reconcileService(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) error {
+	svc := &corev1.Service{}
+
+	// The cluster IP of a Service is immutable, so switching between a
+	// headless and a regular Service requires recreating it.
+	err := r.Get(ctx, client.ObjectKey{Name: cc.Name, Namespace: cc.Namespace}, svc)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("get service %s: %w", cc.Name, err)
+	case isHeadless(svc) != (serviceType(cc) == rampupv1alpha1.ServiceTypeHeadless):
+		if err := r.Delete(ctx, svc); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("delete service %s to change its type: %w", svc.Name, err)
+		}
+		r.Recorder.Eventf(cc, corev1.EventTypeNormal, eventReasonServiceRecreated,
+			"Deleted Service %s to recreate it as %s", svc.Name, serviceType(cc))
+	}
+
+	svc = serviceForCharacterCounter(cc)
+	op, err := r.apply(ctx, cc, svc, serviceDrift)
+	if err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("reconciled service", "service", svc.Name, "operation", op)
+	r.recordOperation(cc, op, eventReasonServiceCreated, eventReasonServiceUpdated, "Service", svc.Name)
+	return nil
+}
+
+// serviceForCharacterCounter renders the Service exposing the server of cc.
+// It only contains the fields managed by the operator, so allocated cluster
+// IPs and node ports are kept by the API server.
+func serviceForCharacterCounter(cc *rampupv1alpha1.CharacterCounter) *corev1.Service {
+	labels := labelsForCharacterCounter(cc)
+
+	svc := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cc.Name,
+			Namespace: cc.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{{
+				Name:        grpcPortName,
+				Port:        cc.Spec.Port,
+				TargetPort:  intstr.FromString(grpcPortName),
+				Protocol:    corev1.ProtocolTCP,
+				AppProtocol: pointer.String(grpcAppProtocol),
+			}},
+		},
+	}
+	if port := httpPort(cc); port != 0 {
+		appProtocol := httpAppProtocol
+		if tlsEnabled(cc) {
+			appProtocol = httpsAppProtocol
+		}
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:        httpPortName,
+			Port:        port,
+			TargetPort:  intstr.FromString(httpPortName),
+			Protocol:    corev1.ProtocolTCP,
+			AppProtocol: pointer.String(appProtocol),
+		})
+	}
+	if cc.Spec.Monitoring.Enabled {
+		// Metrics are always served in plaintext.
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:        metricsPortName,
+			Port:        metricsPort(cc),
+			TargetPort:  intstr.FromString(metricsPortName),
+			Protocol:    corev1.ProtocolTCP,
+			AppProtocol: pointer.String(httpAppProtocol),
+		})
+	}
+
+	switch t := serviceType(cc); t {
+	case rampupv1alpha1.ServiceTypeHeadless:
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		svc.Spec.ClusterIP = corev1.ClusterIPNone
+	default:
+		svc.Spec.Type = corev1.ServiceType(t)
+	}
+	return svc
+}
+
+// serviceType returns the Service type requested by cc, defaulting to ClusterIP.
+func serviceType(cc *rampupv1alpha1.CharacterCounter) rampupv1alpha1.ServiceType {
+	if cc.Spec.Service.Type == "" {
+		return rampupv1alpha1.ServiceTypeClusterIP
+	}
+	return cc.Spec.Service.Type
+}
+
+// isHeadless reports whether svc has no cluster IP.
+func isHeadless(svc *corev1.Service) bool {
+	return svc.Spec.ClusterIP == corev1.ClusterIPNone
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+func TestServiceForCharacterCounter(t *testing.T) {
+	tests := []struct {
+		name         string
+		serviceType  rampupv1alpha1.ServiceType
+		wantType     corev1.ServiceType
+		wantHeadless bool
+	}{
+		{name: "default", wantType: corev1.ServiceTypeClusterIP},
+		{name: "node port", serviceType: rampupv1alpha1.ServiceTypeNodePort, wantType: corev1.ServiceTypeNodePort},
+		{name: "load balancer", serviceType: rampupv1alpha1.ServiceTypeLoadBalancer, wantType: corev1.ServiceTypeLoadBalancer},
+		{name: "headless", serviceType: rampupv1alpha1.ServiceTypeHeadless, wantType: corev1.ServiceTypeClusterIP, wantHeadless: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := newTestCharacterCounter()
+			cc.Spec.Service.Type = tt.serviceType
+			svc := serviceForCharacterCounter(cc)
+
+			if svc.Spec.Type != tt.wantType {
+				t.Errorf("type = %q, want %q", svc.Spec.Type, tt.wantType)
+			}
+			if isHeadless(svc) != tt.wantHeadless {
+				t.Errorf("headless = %t, want %t", isHeadless(svc), tt.wantHeadless)
+			}
+			p := svc.Spec.Ports[0]
+			if p.Name != grpcPortName || p.Port != cc.Spec.Port || p.AppProtocol == nil || *p.AppProtocol != grpcAppProtocol {
+				t.Errorf("unexpected service port %+v", p)
+			}
+		})
+	}
+}
+
+func TestServiceForCharacterCounterHTTP(t *testing.T) {
+	cc := newTestCharacterCounter()
+	if n := len(serviceForCharacterCounter(cc).Spec.Ports); n != 1 {
+		t.Fatalf("got %d ports with the HTTP gateway disabled, want 1", n)
+	}
+
+	cc.Spec.HTTP = rampupv1alpha1.HTTPSpec{Enabled: true, Port: 8080}
+	svc := serviceForCharacterCounter(cc)
+	if n := len(svc.Spec.Ports); n != 2 {
+		t.Fatalf("got %d ports with the HTTP gateway enabled, want 2", n)
+	}
+	if p := svc.Spec.Ports[1]; p.Name != httpPortName || p.Port != 8080 || p.TargetPort.StrVal != httpPortName {
+		t.Errorf("unexpected HTTP service port %+v", p)
+	}
+
+	dep := deploymentForCharacterCounter(cc, "hash")
+	if ports := dep.Spec.Template.Spec.Containers[0].Ports; len(ports) != 2 || ports[1].ContainerPort != 8080 {
+		t.Errorf("unexpected container ports %+v", ports)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+// metricsPath is the path the server serves its metrics on.
+const metricsPath = "/metrics"
+
+// serviceMonitorGVK is the kind of the ServiceMonitors of the Prometheus
+// operator. They are handled as unstructured objects, so the operator does
+// not depend on the Prometheus operator API and runs without its CRDs as
+// long as monitoring is disabled.
+var serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+// reconcileServiceMonitor applies the ServiceMonitor scraping the metrics of
+// the server if monitoring is enabled, and deletes it otherwise.
+func (r *CharacterCounterReconciler) reconcileServiceMonitor(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) error {
+	if !cc.Spec.Monitoring.Enabled {
+		return r.deleteServiceMonitor(ctx, cc)
+	}
+
+	sm := serviceMonitorForCharacterCounter(cc)
+	op, err := r.apply(ctx, cc, sm, serviceMonitorDrift)
+	if meta.IsNoMatchError(err) {
+		return fmt.Errorf("monitoring requires the ServiceMonitor CRD of the Prometheus operator: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("reconciled service monitor", "serviceMonitor", sm.GetName(), "operation", op)
+	r.recordOperation(cc, op, eventReasonServiceMonitorCreated, eventReasonServiceMonitorUpdated, serviceMonitorGVK.Kind, sm.GetName())
+	return nil
+}
+
+// deleteServiceMonitor deletes the ServiceMonitor of cc after monitoring was
+// disabled, so Prometheus stops scraping the no longer exposed port. It is
+// only looked up if ServiceMonitors are watched, so the lookup is served by
+// the cache instead of the API server on every reconciliation. Without their
+// CRD there is nothing to delete.
+func (r *CharacterCounterReconciler) deleteServiceMonitor(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) error {
+	if !r.watchesServiceMonitors {
+		return nil
+	}
+	sm := newServiceMonitor(cc)
+	err := r.Get(ctx, client.ObjectKeyFromObject(sm), sm)
+	switch {
+	case apierrors.IsNotFound(err) || meta.IsNoMatchError(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("get ServiceMonitor %s: %w", sm.GetName(), err)
+	case !metav1.IsControlledBy(sm, cc):
+		return nil
+	}
+	if err := r.Delete(ctx, sm); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("delete ServiceMonitor %s: %w", sm.GetName(), err)
+	}
+	r.Recorder.Eventf(cc, corev1.EventTypeNormal, eventReasonDependentDeleted, "Deleted ServiceMonitor %s", sm.GetName())
+	return nil
+}
+
+// newServiceMonitor returns an empty ServiceMonitor with the name of cc.
+func newServiceMonitor(cc *rampupv1alpha1.CharacterCounter) *unstructured.Unstructured {
+	sm := &unstructured.Unstructured{}
+	sm.SetGroupVersionKind(serviceMonitorGVK)
+	sm.SetName(cc.Name)
+	sm.SetNamespace(cc.Namespace)
+	return sm
+}
+
+// serviceMonitorForCharacterCounter renders the ServiceMonitor scraping the
+// metrics port of the Service of cc.
+func serviceMonitorForCharacterCounter(cc *rampupv1alpha1.CharacterCounter) *unstructured.Unstructured {
+	labels := map[string]interface{}{}
+	for k, v := range labelsForCharacterCounter(cc) {
+		labels[k] = v
+	}
+	endpoint := map[string]interface{}{
+		"port":   metricsPortName,
+		"path":   metricsPath,
+		"scheme": "http",
+	}
+	if cc.Spec.Monitoring.Interval != "" {
+		endpoint["interval"] = cc.Spec.Monitoring.Interval
+	}
+
+	sm := newServiceMonitor(cc)
+	sm.SetLabels(labelsForCharacterCounter(cc))
+	sm.Object["spec"] = map[string]interface{}{
+		"endpoints": []interface{}{endpoint},
+		"selector":  map[string]interface{}{"matchLabels": labels},
+	}
+	return sm
+}
+
+// serviceMonitorDrift is the driftFunc of the ServiceMonitor. It checks the spec.
+func serviceMonitorDrift(desiredObj, liveObj client.Object, ignored sets.Set[string]) []string {
+	desired, live := desiredObj.(*unstructured.Unstructured), liveObj.(*unstructured.Unstructured)
+	d := &driftChecker{live: live, ignored: ignored}
+
+	d.check("spec", equality.Semantic.DeepEqual(desired.Object["spec"], live.Object["spec"]),
+		func() { desired.Object["spec"] = live.Object["spec"] },
+		"f:spec")
+
+	return d.drifted
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+func TestServiceMonitorForCharacterCounter(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.Monitoring = rampupv1alpha1.MonitoringSpec{Enabled: true, Port: 9191, Interval: "30s"}
+	sm := serviceMonitorForCharacterCounter(cc)
+
+	if sm.GroupVersionKind() != serviceMonitorGVK || sm.GetName() != cc.Name || sm.GetNamespace() != cc.Namespace {
+		t.Errorf("unexpected ServiceMonitor %s %s/%s", sm.GroupVersionKind(), sm.GetNamespace(), sm.GetName())
+	}
+	endpoints, _, _ := unstructured.NestedSlice(sm.Object, "spec", "endpoints")
+	if len(endpoints) != 1 {
+		t.Fatalf("got %d endpoints, want 1", len(endpoints))
+	}
+	if ep := endpoints[0].(map[string]interface{}); ep["port"] != metricsPortName || ep["path"] != metricsPath || ep["interval"] != "30s" {
+		t.Errorf("unexpected endpoint %v", ep)
+	}
+	selector, _, _ := unstructured.NestedStringMap(sm.Object, "spec", "selector", "matchLabels")
+	svc := serviceForCharacterCounter(cc)
+	for k, v := range svc.Labels {
+		if selector[k] != v {
+			t.Errorf("selector %v does not select the Service labeled %v", selector, svc.Labels)
+			break
+		}
+	}
+	if p := svc.Spec.Ports[len(svc.Spec.Ports)-1]; p.Name != metricsPortName || p.Port != 9191 || p.TargetPort.StrVal != metricsPortName {
+		t.Errorf("unexpected metrics service port %+v", p)
+	}
+
+	ports := deploymentForCharacterCounter(cc, "hash").Spec.Template.Spec.Containers[0].Ports
+	if p := ports[len(ports)-1]; p.Name != metricsPortName || p.ContainerPort != 9191 {
+		t.Errorf("unexpected metrics container port %+v", p)
+	}
+	data, err := configMapData(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg serverConfig
+	if err := json.Unmarshal([]byte(data[configFileName]), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MetricsPort != 9191 {
+		t.Errorf("metrics port = %d, want 9191", cfg.MetricsPort)
+	}
+}
+
+func TestServiceMonitorDrift(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.Spec.Monitoring.Enabled = true
+	desired := serviceMonitorForCharacterCounter(cc)
+	live := desired.DeepCopy()
+	if drifted := serviceMonitorDrift(desired, live, sets.New[string]()); len(drifted) != 0 {
+		t.Errorf("unchanged ServiceMonitor drifted in %v", drifted)
+	}
+
+	if err := unstructured.SetNestedSlice(live.Object, nil, "spec", "endpoints"); err != nil {
+		t.Fatal(err)
+	}
+	live.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager:  "kubectl-edit",
+		FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:endpoints":{}}}`)},
+	}})
+	if drifted := serviceMonitorDrift(desired, live, sets.New[string]()); len(drifted) != 1 || drifted[0] != "spec" {
+		t.Errorf("drifted = %v, want [spec]", drifted)
+	}
+}
+
+func TestDeleteServiceMonitor(t *testing.T) {
+	cc := newTestCharacterCounter()
+	cc.UID = "uid"
+	sm := serviceMonitorForCharacterCounter(cc)
+	sm.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(cc, rampupv1alpha1.GroupVersion.WithKind("CharacterCounter"))})
+
+	tests := []struct {
+		name       string
+		watched    bool
+		wantGets   int
+		wantDelete bool
+	}{
+		{name: "not watched", wantGets: 0},
+		{name: "watched", watched: true, wantGets: 1, wantDelete: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gets := 0
+			c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(sm.DeepCopy()).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+						gets++
+						return c.Get(ctx, key, obj, opts...)
+					},
+				}).Build()
+			r := &CharacterCounterReconciler{Client: c, Recorder: record.NewFakeRecorder(10), watchesServiceMonitors: tt.watched}
+
+			if err := r.deleteServiceMonitor(context.Background(), cc); err != nil {
+				t.Fatal(err)
+			}
+			if gets != tt.wantGets {
+				t.Errorf("gets = %d, want %d", gets, tt.wantGets)
+			}
+			err := c.Get(context.Background(), client.ObjectKeyFromObject(sm), newServiceMonitor(cc))
+			if deleted := apierrors.IsNotFound(err); deleted != tt.wantDelete {
+				t.Errorf("deleted = %t (%v), want %t", deleted, err, tt.wantDelete)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+// Reasons of the CharacterCounter conditions.
+const (
+	reasonDeploymentNotFound         = "DeploymentNotFound"
+	reasonMinimumReplicasAvailable   = "MinimumReplicasAvailable"
+	reasonMinimumReplicasUnavailable = "MinimumReplicasUnavailable"
+	reasonRollingOut                 = "RollingOut"
+	reasonRolloutComplete            = "RolloutComplete"
+	reasonReconcileFailed            = "ReconcileFailed"
+	reasonFieldConflict              = "FieldConflict"
+	reasonProgressDeadlineExceeded   = "ProgressDeadlineExceeded"
+	reasonReplicaFailure             = "ReplicaFailure"
+	reasonAsExpected                 = "AsExpected"
+	reasonPausedBySpec               = "PausedBySpec"
+	reasonPausedByAnnotation         = "PausedByAnnotation"
+	reasonNotPaused                  = "NotPaused"
+)
+
+// updateStatus computes the status of cc from its Deployment and Service and
+// writes it to the status subresource. reconcileErr is the error of the
+// preceding reconciliation, if any, and marks cc as degraded.
+func (r *CharacterCounterReconciler) updateStatus(ctx context.Context, cc *rampupv1alpha1.CharacterCounter, reconcileErr error) error {
+	key := client.ObjectKey{Name: cc.Name, Namespace: cc.Namespace}
+
+	dep := &appsv1.Deployment{}
+	if err := r.Get(ctx, key, dep); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("get deployment %s: %w", key.Name, err)
+		}
+		dep = nil
+	}
+
+	svc := &corev1.Service{}
+	if err := r.Get(ctx, key, svc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("get service %s: %w", key.Name, err)
+		}
+		svc = nil
+	}
+
+	setStatus(cc, dep, svc, reconcileErr)
+	if err := r.Status().Update(ctx, cc); err != nil {
+		return fmt.Errorf("update status: %w", err)
+	}
+	return nil
+}
+
+// setStatus sets the status of cc from the observed dep and svc, which may be
+// nil if they do not exist.
+func setStatus(cc *rampupv1alpha1.CharacterCounter, dep *appsv1.Deployment, svc *corev1.Service, reconcileErr error) {
+	cc.Status.ObservedGeneration = cc.Generation
+	cc.Status.Replicas = 0
+	cc.Status.ReadyReplicas = 0
+	cc.Status.Endpoint = ""
+	if dep != nil {
+		cc.Status.Replicas = dep.Status.Replicas
+		cc.Status.ReadyReplicas = dep.Status.ReadyReplicas
+	}
+	if svc != nil {
+		cc.Status.Endpoint = serviceEndpoint(svc, cc.Spec.Port)
+	}
+
+	conditions := deploymentConditions(dep, reconcileErr)
+	conditions = append(conditions, pausedCondition(cc))
+	for _, c := range conditions {
+		c.ObservedGeneration = cc.Generation
+		meta.SetStatusCondition(&cc.Status.Conditions, c)
+	}
+}
+
+// deploymentConditions derives the Available, Progressing and Degraded
+// conditions from the state of dep.
+func deploymentConditions(dep *appsv1.Deployment, reconcileErr error) []metav1.Condition {
+	available := metav1.Condition{Type: rampupv1alpha1.ConditionAvailable}
+	progressing := metav1.Condition{Type: rampupv1alpha1.ConditionProgressing}
+	degraded := metav1.Condition{
+		Type:    rampupv1alpha1.ConditionDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  reasonAsExpected,
+		Message: "The server is reconciled",
+	}
+
+	if dep == nil {
+		available.Status, available.Reason = metav1.ConditionFalse, reasonDeploymentNotFound
+		available.Message = "The server deployment does not exist"
+		progressing.Status, progressing.Reason = metav1.ConditionFalse, reasonDeploymentNotFound
+		progressing.Message = available.Message
+	} else {
+		if c := deploymentCondition(dep, appsv1.DeploymentAvailable); c != nil && c.Status == corev1.ConditionTrue {
+			available.Status, available.Reason = metav1.ConditionTrue, reasonMinimumReplicasAvailable
+		} else {
+			available.Status, available.Reason = metav1.ConditionFalse, reasonMinimumReplicasUnavailable
+		}
+		available.Message = fmt.Sprintf("%d of %d replicas are available", dep.Status.AvailableReplicas, desiredReplicas(dep))
+
+		if rollingOut(dep) {
+			progressing.Status, progressing.Reason = metav1.ConditionTrue, reasonRollingOut
+			progressing.Message = fmt.Sprintf("%d of %d replicas are updated", dep.Status.UpdatedReplicas, desiredReplicas(dep))
+		} else {
+			progressing.Status, progressing.Reason = metav1.ConditionFalse, reasonRolloutComplete
+			progressing.Message = "The server deployment is rolled out"
+		}
+
+		if c := deploymentCondition(dep, appsv1.DeploymentProgressing); c != nil && c.Reason == reasonProgressDeadlineExceeded {
+			degraded.Status, degraded.Reason, degraded.Message = metav1.ConditionTrue, reasonProgressDeadlineExceeded, c.Message
+		}
+		if c := deploymentCondition(dep, appsv1.DeploymentReplicaFailure); c != nil && c.Status == corev1.ConditionTrue {
+			degraded.Status, degraded.Reason, degraded.Message = metav1.ConditionTrue, reasonReplicaFailure, c.Message
+		}
+	}
+
+	var conflictErr *applyConflictError
+	switch {
+	case errors.As(reconcileErr, &conflictErr):
+		degraded.Status, degraded.Reason, degraded.Message = metav1.ConditionTrue, reasonFieldConflict, conflictErr.Error()
+	case reconcileErr != nil:
+		degraded.Status, degraded.Reason, degraded.Message = metav1.ConditionTrue, reasonReconcileFailed, reconcileErr.Error()
+	}
+
+	return []metav1.Condition{available, progressing, degraded}
+}
+
+// pausedCondition returns the Paused condition of cc.
+func pausedCondition(cc *rampupv1alpha1.CharacterCounter) metav1.Condition {
+	switch {
+	case cc.Spec.Paused:
+		return metav1.Condition{
+			Type:    rampupv1alpha1.ConditionPaused,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonPausedBySpec,
+			Message: "Reconciliation is paused by spec.paused",
+		}
+	case cc.Annotations[rampupv1alpha1.PausedAnnotation] == "true":
+		return metav1.Condition{
+			Type:    rampupv1alpha1.ConditionPaused,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonPausedByAnnotation,
+			Message: "Reconciliation is paused by the " + rampupv1alpha1.PausedAnnotation + " annotation",
+		}
+	default:
+		return metav1.Condition{
+			Type:    rampupv1alpha1.ConditionPaused,
+			Status:  metav1.ConditionFalse,
+			Reason:  reasonNotPaused,
+			Message: "Reconciliation is active",
+		}
+	}
+}
+
+// isPaused reports whether the reconciliation of the owned objects of cc is paused.
+func isPaused(cc *rampupv1alpha1.CharacterCounter) bool {
+	return pausedCondition(cc).Status == metav1.ConditionTrue
+}
+
+// rollingOut reports whether dep has not yet fully rolled out its latest spec.
+func rollingOut(dep *appsv1.Deployment) bool {
+	desired := desiredReplicas(dep)
+	return dep.Status.ObservedGeneration < dep.Generation ||
+		dep.Status.UpdatedReplicas < desired ||
+		dep.Status.Replicas > dep.Status.UpdatedReplicas ||
+		dep.Status.AvailableReplicas < dep.Status.UpdatedReplicas
+}
+
+// desiredReplicas returns the number of replicas requested by dep.
+func desiredReplicas(dep *appsv1.Deployment) int32 {
+	if dep.Spec.Replicas == nil {
+		return 1
+	}
+	return *dep.Spec.Replicas
+}
+
+// deploymentCondition returns the condition of type t of dep, or nil.
+func deploymentCondition(dep *appsv1.Deployment, t appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range dep.Status.Conditions {
+		if dep.Status.Conditions[i].Type == t {
+			return &dep.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// serviceEndpoint returns the address clients use to reach svc on port.
+// Load balancers are reached through their ingress, everything else through
+// the cluster DNS name of the Service.
+func serviceEndpoint(svc *corev1.Service, port int32) string {
+	host := fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for _, ing := range svc.Status.LoadBalancer.Ingress {
+			if ing.Hostname != "" {
+				host = ing.Hostname
+				break
+			}
+			if ing.IP != "" {
+				host = ing.IP
+				break
+			}
+		}
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+func TestSetStatus(t *testing.T) {
+	rolledOut := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 3},
+		Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32(2)},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 3,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			ReadyReplicas:      2,
+			AvailableReplicas:  2,
+			Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	rollingOut := rolledOut.DeepCopy()
+	rollingOut.Status.UpdatedReplicas = 1
+	stuck := rollingOut.DeepCopy()
+	stuck.Status.Conditions = append(stuck.Status.Conditions, appsv1.DeploymentCondition{
+		Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: reasonProgressDeadlineExceeded,
+	})
+
+	tests := []struct {
+		name         string
+		dep          *appsv1.Deployment
+		err          error
+		want         map[string]metav1.ConditionStatus
+		wantReplicas int32
+	}{
+		{
+			name: "missing deployment",
+			want: map[string]metav1.ConditionStatus{
+				rampupv1alpha1.ConditionAvailable:   metav1.ConditionFalse,
+				rampupv1alpha1.ConditionProgressing: metav1.ConditionFalse,
+				rampupv1alpha1.ConditionDegraded:    metav1.ConditionFalse,
+			},
+		},
+		{
+			name:         "rolled out",
+			dep:          rolledOut,
+			wantReplicas: 2,
+			want: map[string]metav1.ConditionStatus{
+				rampupv1alpha1.ConditionAvailable:   metav1.ConditionTrue,
+				rampupv1alpha1.ConditionProgressing: metav1.ConditionFalse,
+				rampupv1alpha1.ConditionDegraded:    metav1.ConditionFalse,
+			},
+		},
+		{
+			name:         "rolling out",
+			dep:          rollingOut,
+			wantReplicas: 2,
+			want: map[string]metav1.ConditionStatus{
+				rampupv1alpha1.ConditionAvailable:   metav1.ConditionTrue,
+				rampupv1alpha1.ConditionProgressing: metav1.ConditionTrue,
+				rampupv1alpha1.ConditionDegraded:    metav1.ConditionFalse,
+			},
+		},
+		{
+			name:         "progress deadline exceeded",
+			dep:          stuck,
+			wantReplicas: 2,
+			want: map[string]metav1.ConditionStatus{
+				rampupv1alpha1.ConditionProgressing: metav1.ConditionTrue,
+				rampupv1alpha1.ConditionDegraded:    metav1.ConditionTrue,
+			},
+		},
+		{
+			name:         "reconcile failed",
+			dep:          rolledOut,
+			err:          errors.New("boom"),
+			wantReplicas: 2,
+			want: map[string]metav1.ConditionStatus{
+				rampupv1alpha1.ConditionAvailable: metav1.ConditionTrue,
+				rampupv1alpha1.ConditionDegraded:  metav1.ConditionTrue,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := newTestCharacterCounter()
+			cc.Generation = 7
+			setStatus(cc, tt.dep, nil, tt.err)
+
+			if cc.Status.ObservedGeneration != 7 {
+				t.Errorf("observedGeneration = %d, want 7", cc.Status.ObservedGeneration)
+			}
+			if cc.Status.ReadyReplicas != tt.wantReplicas {
+				t.Errorf("readyReplicas = %d, want %d", cc.Status.ReadyReplicas, tt.wantReplicas)
+			}
+			for typ, want := range tt.want {
+				c := meta.FindStatusCondition(cc.Status.Conditions, typ)
+				if c == nil {
+					t.Fatalf("condition %s not set", typ)
+				}
+				if c.Status != want {
+					t.Errorf("condition %s = %s, want %s", typ, c.Status, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceEndpoint(t *testing.T) {
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"}}
+	if got, want := serviceEndpoint(svc, 50051), "sample.default.svc:50051"; got != want {
+		t.Errorf("endpoint = %q, want %q", got, want)
+	}
+
+	svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+	svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.7"}}
+	if got, want := serviceEndpoint(svc, 50051), "203.0.113.7:50051"; got != want {
+		t.Errorf("endpoint = %q, want %q", got, want)
+	}
+}
+
+func TestIsPaused(t *testing.T) {
+	cc := newTestCharacterCounter()
+	if isPaused(cc) {
+		t.Error("new CharacterCounter is paused")
+	}
+
+	cc.Spec.Paused = true
+	if !isPaused(cc) {
+		t.Error("CharacterCounter with spec.paused is not paused")
+	}
+
+	cc.Spec.Paused = false
+	cc.Annotations = map[string]string{rampupv1alpha1.PausedAnnotation: "true"}
+	if !isPaused(cc) {
+		t.Error("CharacterCounter with paused annotation is not paused")
+	}
+}
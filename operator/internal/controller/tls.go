@@ -0,0 +1,241 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rampupv1alpha1 "github.com/jonas27/ramp-up-k8s-operator/operator/api/v1alpha1"
+)
+
+const (
+	// tlsVolumeName is the name of the volume holding the certificates.
+	tlsVolumeName = "tls"
+	// tlsMountPath is the directory the certificates are mounted to.
+	tlsMountPath = "/etc/character-counter/tls"
+	// clientCAFileName is the file holding the client CAs in the TLS volume.
+	clientCAFileName = "client-ca.crt"
+	// healthPortName is the name of the plaintext health port on the container.
+	healthPortName = "health"
+
+	// clusterDomain is the DNS domain of the cluster the serving certificate
+	// is issued for.
+	clusterDomain = "cluster.local"
+)
+
+// reconcileTLS applies the Secrets holding the generated CA and serving
+// certificate of cc in Auto mode, and checks that the referenced Secrets
+// exist. It returns when the certificates have to be renewed, or
+// the zero time if they are not generated.
+func (r *CharacterCounterReconciler) reconcileTLS(ctx context.Context, cc *rampupv1alpha1.CharacterCounter) (time.Time, error) {
+	if !tlsEnabled(cc) {
+		return time.Time{}, nil
+	}
+	// Missing Secrets would only show up as pods stuck in ContainerCreating.
+	for _, name := range tlsReferencedSecretNames(cc) {
+		if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: cc.Namespace}, &corev1.Secret{}); err != nil {
+			return time.Time{}, fmt.Errorf("get TLS secret %s: %w", name, err)
+		}
+	}
+	if cc.Spec.TLS.Mode == rampupv1alpha1.TLSModeProvided {
+		return time.Time{}, nil
+	}
+
+	caLive, err := r.secretData(ctx, cc.Namespace, caSecretName(cc))
+	if err != nil {
+		return time.Time{}, err
+	}
+	servingLive, err := r.secretData(ctx, cc.Namespace, servingSecretName(cc))
+	if err != nil {
+		return time.Time{}, err
+	}
+	caData, servingData, renewAt, err := tlsSecretsData(cc, caLive, servingLive, time.Now())
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, secret := range []*corev1.Secret{
+		tlsSecretForCharacterCounter(cc, caSecretName(cc), caData),
+		tlsSecretForCharacterCounter(cc, servingSecretName(cc), servingData),
+	} {
+		op, err := r.apply(ctx, cc, secret, secretDrift)
+		if err != nil {
+			return time.Time{}, err
+		}
+		log.FromContext(ctx).Info("reconciled secret", "secret", secret.Name, "operation", op)
+		r.recordOperation(cc, op, eventReasonSecretCreated, eventReasonSecretUpdated, "Secret", secret.Name)
+	}
+	return renewAt, nil
+}
+
+// secretData returns the data of the Secret name, or nil if it does not exist.
+func (r *CharacterCounterReconciler) secretData(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("get secret %s: %w", name, err)
+	}
+	return secret.Data, nil
+}
+
+// tlsSecretsData renders the data of the CA and serving certificate Secrets
+// of cc from their live data at now. Certificates that are valid and not due
+// for renewal are kept, the others are generated. It also returns when the
+// first of them has to be renewed.
+func tlsSecretsData(cc *rampupv1alpha1.CharacterCounter, caLive, servingLive map[string][]byte, now time.Time,
+) (caData, servingData map[string][]byte, renewAt time.Time, err error) {
+	ca, err := parseKeyPair(caLive[corev1.TLSCertKey], caLive[corev1.TLSPrivateKeyKey])
+	if err != nil || now.After(ca.renewAt()) {
+		if ca, err = newCA(cc.Name, now); err != nil {
+			return nil, nil, time.Time{}, fmt.Errorf("generate CA: %w", err)
+		}
+	}
+	bundle := caBundle(ca, caLive[corev1.ServiceAccountRootCAKey], now)
+
+	dnsNames := serviceDNSNames(cc)
+	serving, err := parseKeyPair(servingLive[corev1.TLSCertKey], servingLive[corev1.TLSPrivateKeyKey])
+	if err != nil || !validServingCert(serving, ca, dnsNames, now) {
+		if serving, err = newServingCert(ca, dnsNames, now); err != nil {
+			return nil, nil, time.Time{}, fmt.Errorf("generate serving certificate: %w", err)
+		}
+	}
+
+	renewAt = ca.renewAt()
+	if serving.renewAt().Before(renewAt) {
+		renewAt = serving.renewAt()
+	}
+	caData = map[string][]byte{
+		corev1.TLSCertKey:              ca.certPEM,
+		corev1.TLSPrivateKeyKey:        ca.keyPEM,
+		corev1.ServiceAccountRootCAKey: bundle,
+	}
+	servingData = map[string][]byte{
+		corev1.TLSCertKey:              serving.certPEM,
+		corev1.TLSPrivateKeyKey:        serving.keyPEM,
+		corev1.ServiceAccountRootCAKey: bundle,
+	}
+	return caData, servingData, renewAt, nil
+}
+
+// tlsSecretForCharacterCounter renders the kubernetes.io/tls Secret name of cc.
+func tlsSecretForCharacterCounter(cc *rampupv1alpha1.CharacterCounter, name string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cc.Namespace,
+			Labels:    labelsForCharacterCounter(cc),
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: data,
+	}
+}
+
+// tlsVolume returns the projected volume holding the serving certificate of
+// cc and, if client certificates are verified, the client CAs.
+func tlsVolume(cc *rampupv1alpha1.CharacterCounter) corev1.Volume {
+	sources := []corev1.VolumeProjection{{Secret: &corev1.SecretProjection{
+		LocalObjectReference: corev1.LocalObjectReference{Name: servingSecretName(cc)},
+		Items: []corev1.KeyToPath{
+			{Key: corev1.TLSCertKey, Path: corev1.TLSCertKey},
+			{Key: corev1.TLSPrivateKeyKey, Path: corev1.TLSPrivateKeyKey},
+		},
+	}}}
+	if clientAuth(cc) != rampupv1alpha1.TLSClientAuthNone {
+		sources = append(sources, corev1.VolumeProjection{Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: clientCASecretName(cc)},
+			Items:                []corev1.KeyToPath{{Key: corev1.ServiceAccountRootCAKey, Path: clientCAFileName}},
+		}})
+	}
+	return corev1.Volume{
+		Name:         tlsVolumeName,
+		VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: sources}},
+	}
+}
+
+// tlsReferencedSecretNames returns the names of the TLS Secrets cc uses
+// without owning them.
+func tlsReferencedSecretNames(cc *rampupv1alpha1.CharacterCounter) []string {
+	if !tlsEnabled(cc) {
+		return nil
+	}
+	var names []string
+	if cc.Spec.TLS.Mode == rampupv1alpha1.TLSModeProvided {
+		names = append(names, servingSecretName(cc))
+	}
+	if clientAuth(cc) != rampupv1alpha1.TLSClientAuthNone && cc.Spec.TLS.ClientCASecretRef != nil {
+		names = append(names, cc.Spec.TLS.ClientCASecretRef.Name)
+	}
+	return names
+}
+
+// tlsEnabled reports whether the server of cc serves TLS.
+func tlsEnabled(cc *rampupv1alpha1.CharacterCounter) bool {
+	return cc.Spec.TLS != nil
+}
+
+// clientAuth returns the client certificate policy of cc, defaulting to None.
+func clientAuth(cc *rampupv1alpha1.CharacterCounter) rampupv1alpha1.TLSClientAuth {
+	if cc.Spec.TLS == nil || cc.Spec.TLS.ClientAuth == "" {
+		return rampupv1alpha1.TLSClientAuthNone
+	}
+	return cc.Spec.TLS.ClientAuth
+}
+
+// servingSecretName returns the name of the Secret holding the serving
+// certificate of cc.
+func servingSecretName(cc *rampupv1alpha1.CharacterCounter) string {
+	if cc.Spec.TLS != nil && cc.Spec.TLS.Mode == rampupv1alpha1.TLSModeProvided && cc.Spec.TLS.SecretRef != nil {
+		return cc.Spec.TLS.SecretRef.Name
+	}
+	return cc.Name + "-tls"
+}
+
+// caSecretName returns the name of the Secret holding the generated CA of cc.
+func caSecretName(cc *rampupv1alpha1.CharacterCounter) string {
+	return cc.Name + "-ca"
+}
+
+// clientCASecretName returns the name of the Secret holding the CAs client
+// certificates of cc are verified against.
+func clientCASecretName(cc *rampupv1alpha1.CharacterCounter) string {
+	if cc.Spec.TLS != nil && cc.Spec.TLS.ClientCASecretRef != nil {
+		return cc.Spec.TLS.ClientCASecretRef.Name
+	}
+	return servingSecretName(cc)
+}
+
+// serviceDNSNames returns the DNS names of the Service of cc.
+func serviceDNSNames(cc *rampupv1alpha1.CharacterCounter) []string {
+	return []string{
+		cc.Name,
+		cc.Name + "." + cc.Namespace,
+		cc.Name + "." + cc.Namespace + ".svc",
+		cc.Name + "." + cc.Namespace + ".svc." + clusterDomain,
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTLSSecretsData(t *testing.T) {
+	cc := newTestCharacterCounter()
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	caData, servingData, renewAt, err := tlsSecretsData(cc, nil, nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serving, err := parseKeyPair(servingData[corev1.TLSCertKey], servingData[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !renewAt.Equal(serving.renewAt()) || renewAt.Before(now.Add(servingCertValidity/2)) {
+		t.Errorf("renewAt = %s, want two thirds into the serving certificate lifetime", renewAt)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(servingData[corev1.ServiceAccountRootCAKey]) {
+		t.Fatal("no CA in ca.crt of the serving certificate Secret")
+	}
+	if _, err := serving.cert.Verify(x509.VerifyOptions{
+		DNSName: "sample.default.svc", Roots: roots, CurrentTime: now,
+	}); err != nil {
+		t.Errorf("serving certificate does not verify for the Service: %v", err)
+	}
+
+	// Valid certificates are kept.
+	caAgain, servingAgain, _, err := tlsSecretsData(cc, caData, servingData, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(caAgain[corev1.TLSCertKey], caData[corev1.TLSCertKey]) ||
+		!bytes.Equal(servingAgain[corev1.TLSCertKey], servingData[corev1.TLSCertKey]) {
+		t.Error("valid certificates were regenerated")
+	}
+
+	// The serving certificate is renewed with the same CA.
+	_, renewed, _, err := tlsSecretsData(cc, caData, servingData, renewAt.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(renewed[corev1.TLSCertKey], servingData[corev1.TLSCertKey]) {
+		t.Error("serving certificate was not renewed")
+	}
+	if !bytes.Equal(renewed[corev1.ServiceAccountRootCAKey], caData[corev1.TLSCertKey]) {
+		t.Error("CA changed with the serving certificate")
+	}
+
+	// A renewed CA is bundled with the previous one until it expires.
+	caRenewAt := now.Add(caValidity * 2 / 3)
+	rotatedCA, _, _, err := tlsSecretsData(cc, caData, servingData, caRenewAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(rotatedCA[corev1.TLSCertKey], caData[corev1.TLSCertKey]) {
+		t.Fatal("CA was not renewed")
+	}
+	wantBundle := append(append([]byte{}, rotatedCA[corev1.TLSCertKey]...), caData[corev1.TLSCertKey]...)
+	if !bytes.Equal(rotatedCA[corev1.ServiceAccountRootCAKey], wantBundle) {
+		t.Error("CA bundle does not hold the renewed and the previous CA")
+	}
+}
+
+func TestTLSSecretsDataServiceRenamed(t *testing.T) {
+	cc := newTestCharacterCounter()
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	caData, servingData, _, err := tlsSecretsData(cc, nil, nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc.Namespace = "other"
+	_, reissued, _, err := tlsSecretsData(cc, caData, servingData, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(reissued[corev1.TLSCertKey], servingData[corev1.TLSCertKey]) {
+		t.Error("serving certificate was not reissued for the new DNS names")
+	}
+}
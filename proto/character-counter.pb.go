@@ -4,11 +4,12 @@
 // 	protoc        v3.12.4
 // source: character-counter.proto
 
-package __
+package proto
 
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -20,6 +21,128 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// The unit characters are counted in
+type CountingMode int32
+
+const (
+	// Counts Unicode code points
+	CountingMode_COUNTING_MODE_UNSPECIFIED CountingMode = 0
+	// Counts the bytes of the UTF-8 encoding
+	CountingMode_COUNTING_MODE_BYTES CountingMode = 1
+	// Counts Unicode code points, like Go runes
+	CountingMode_COUNTING_MODE_CODE_POINTS CountingMode = 2
+	// Counts extended grapheme clusters, i.e. user-perceived characters
+	CountingMode_COUNTING_MODE_GRAPHEMES CountingMode = 3
+	// Counts the code units of the UTF-16 encoding, like JavaScript's String.length
+	CountingMode_COUNTING_MODE_UTF16_CODE_UNITS CountingMode = 4
+)
+
+// Enum value maps for CountingMode.
+var (
+	CountingMode_name = map[int32]string{
+		0: "COUNTING_MODE_UNSPECIFIED",
+		1: "COUNTING_MODE_BYTES",
+		2: "COUNTING_MODE_CODE_POINTS",
+		3: "COUNTING_MODE_GRAPHEMES",
+		4: "COUNTING_MODE_UTF16_CODE_UNITS",
+	}
+	CountingMode_value = map[string]int32{
+		"COUNTING_MODE_UNSPECIFIED":      0,
+		"COUNTING_MODE_BYTES":            1,
+		"COUNTING_MODE_CODE_POINTS":      2,
+		"COUNTING_MODE_GRAPHEMES":        3,
+		"COUNTING_MODE_UTF16_CODE_UNITS": 4,
+	}
+)
+
+func (x CountingMode) Enum() *CountingMode {
+	p := new(CountingMode)
+	*p = x
+	return p
+}
+
+func (x CountingMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CountingMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_character_counter_proto_enumTypes[0].Descriptor()
+}
+
+func (CountingMode) Type() protoreflect.EnumType {
+	return &file_character_counter_proto_enumTypes[0]
+}
+
+func (x CountingMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CountingMode.Descriptor instead.
+func (CountingMode) EnumDescriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{0}
+}
+
+// The Unicode normalization form applied before counting
+type Normalization int32
+
+const (
+	// Counts the text as is
+	Normalization_NORMALIZATION_UNSPECIFIED Normalization = 0
+	// Canonical composition, e.g. "e" and a combining acute accent become "é"
+	Normalization_NORMALIZATION_NFC Normalization = 1
+	// Canonical decomposition, e.g. "é" becomes "e" and a combining acute accent
+	Normalization_NORMALIZATION_NFD Normalization = 2
+	// Compatibility composition, e.g. the ligature "ﬁ" becomes "fi"
+	Normalization_NORMALIZATION_NFKC Normalization = 3
+	// Compatibility decomposition
+	Normalization_NORMALIZATION_NFKD Normalization = 4
+)
+
+// Enum value maps for Normalization.
+var (
+	Normalization_name = map[int32]string{
+		0: "NORMALIZATION_UNSPECIFIED",
+		1: "NORMALIZATION_NFC",
+		2: "NORMALIZATION_NFD",
+		3: "NORMALIZATION_NFKC",
+		4: "NORMALIZATION_NFKD",
+	}
+	Normalization_value = map[string]int32{
+		"NORMALIZATION_UNSPECIFIED": 0,
+		"NORMALIZATION_NFC":         1,
+		"NORMALIZATION_NFD":         2,
+		"NORMALIZATION_NFKC":        3,
+		"NORMALIZATION_NFKD":        4,
+	}
+)
+
+func (x Normalization) Enum() *Normalization {
+	p := new(Normalization)
+	*p = x
+	return p
+}
+
+func (x Normalization) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Normalization) Descriptor() protoreflect.EnumDescriptor {
+	return file_character_counter_proto_enumTypes[1].Descriptor()
+}
+
+func (Normalization) Type() protoreflect.EnumType {
+	return &file_character_counter_proto_enumTypes[1]
+}
+
+func (x Normalization) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Normalization.Descriptor instead.
+func (Normalization) EnumDescriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{1}
+}
+
 // The request message containing the text
 type CountCharactersRequest struct {
 	state         protoimpl.MessageState
@@ -27,25 +150,649 @@ type CountCharactersRequest struct {
 	unknownFields protoimpl.UnknownFields
 
 	Text string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	// The unit to count in
+	Mode CountingMode `protobuf:"varint,2,opt,name=mode,proto3,enum=frontend.CountingMode" json:"mode,omitempty"`
+	// Returns how often every character occurs and the number of distinct characters
+	Histogram bool `protobuf:"varint,3,opt,name=histogram,proto3" json:"histogram,omitempty"`
+	// Limits the histogram to the k most frequent characters, 0 returns all
+	HistogramTopK uint32 `protobuf:"varint,4,opt,name=histogram_top_k,json=histogramTopK,proto3" json:"histogram_top_k,omitempty"`
+	// Normalizes the text before counting. The histogram is computed from the
+	// normalized text
+	Normalization Normalization `protobuf:"varint,5,opt,name=normalization,proto3,enum=frontend.Normalization" json:"normalization,omitempty"`
+}
+
+func (x *CountCharactersRequest) Reset() {
+	*x = CountCharactersRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountCharactersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountCharactersRequest) ProtoMessage() {}
+
+func (x *CountCharactersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountCharactersRequest.ProtoReflect.Descriptor instead.
+func (*CountCharactersRequest) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CountCharactersRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *CountCharactersRequest) GetMode() CountingMode {
+	if x != nil {
+		return x.Mode
+	}
+	return CountingMode_COUNTING_MODE_UNSPECIFIED
+}
+
+func (x *CountCharactersRequest) GetHistogram() bool {
+	if x != nil {
+		return x.Histogram
+	}
+	return false
+}
+
+func (x *CountCharactersRequest) GetHistogramTopK() uint32 {
+	if x != nil {
+		return x.HistogramTopK
+	}
+	return 0
+}
+
+func (x *CountCharactersRequest) GetNormalization() Normalization {
+	if x != nil {
+		return x.Normalization
+	}
+	return Normalization_NORMALIZATION_UNSPECIFIED
+}
+
+// A chunk of a UTF-8 encoded text. Chunks may split multi-byte sequences
+// and grapheme clusters.
+type CountCharactersChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// The unit to count in, only read from the first chunk
+	Mode CountingMode `protobuf:"varint,2,opt,name=mode,proto3,enum=frontend.CountingMode" json:"mode,omitempty"`
+}
+
+func (x *CountCharactersChunk) Reset() {
+	*x = CountCharactersChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountCharactersChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountCharactersChunk) ProtoMessage() {}
+
+func (x *CountCharactersChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountCharactersChunk.ProtoReflect.Descriptor instead.
+func (*CountCharactersChunk) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CountCharactersChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *CountCharactersChunk) GetMode() CountingMode {
+	if x != nil {
+		return x.Mode
+	}
+	return CountingMode_COUNTING_MODE_UNSPECIFIED
+}
+
+// The response message containing the number characters
+type CountCharactersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Characters uint64 `protobuf:"varint,1,opt,name=characters,proto3" json:"characters,omitempty"`
+	// The response value configured for the server
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// The unit the characters were counted in
+	Mode CountingMode `protobuf:"varint,3,opt,name=mode,proto3,enum=frontend.CountingMode" json:"mode,omitempty"`
+	// The number of distinct characters, only set if the histogram was requested
+	DistinctCharacters uint64 `protobuf:"varint,4,opt,name=distinct_characters,json=distinctCharacters,proto3" json:"distinct_characters,omitempty"`
+	// The characters by descending frequency, only set if the histogram was requested
+	Histogram []*CharacterFrequency `protobuf:"bytes,5,rep,name=histogram,proto3" json:"histogram,omitempty"`
+	// The number of characters of the normalized text, only set if a
+	// normalization was requested. characters always counts the raw text
+	NormalizedCharacters uint64 `protobuf:"varint,6,opt,name=normalized_characters,json=normalizedCharacters,proto3" json:"normalized_characters,omitempty"`
+	// The normalization applied to the text
+	Normalization Normalization `protobuf:"varint,7,opt,name=normalization,proto3,enum=frontend.Normalization" json:"normalization,omitempty"`
+}
+
+func (x *CountCharactersResponse) Reset() {
+	*x = CountCharactersResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountCharactersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountCharactersResponse) ProtoMessage() {}
+
+func (x *CountCharactersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountCharactersResponse.ProtoReflect.Descriptor instead.
+func (*CountCharactersResponse) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CountCharactersResponse) GetCharacters() uint64 {
+	if x != nil {
+		return x.Characters
+	}
+	return 0
+}
+
+func (x *CountCharactersResponse) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *CountCharactersResponse) GetMode() CountingMode {
+	if x != nil {
+		return x.Mode
+	}
+	return CountingMode_COUNTING_MODE_UNSPECIFIED
+}
+
+func (x *CountCharactersResponse) GetDistinctCharacters() uint64 {
+	if x != nil {
+		return x.DistinctCharacters
+	}
+	return 0
+}
+
+func (x *CountCharactersResponse) GetHistogram() []*CharacterFrequency {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (x *CountCharactersResponse) GetNormalizedCharacters() uint64 {
+	if x != nil {
+		return x.NormalizedCharacters
+	}
+	return 0
+}
+
+func (x *CountCharactersResponse) GetNormalization() Normalization {
+	if x != nil {
+		return x.Normalization
+	}
+	return Normalization_NORMALIZATION_UNSPECIFIED
+}
+
+// The frequency of a character in the counting unit
+type CharacterFrequency struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The character as text. Empty for bytes and UTF-16 code units that are
+	// not a character on their own, e.g. a byte of a multi-byte sequence
+	Character string `protobuf:"bytes,1,opt,name=character,proto3" json:"character,omitempty"`
+	// The value of the byte, code point or UTF-16 code unit. Zero for graphemes
+	Value uint32 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	// How often the character occurs
+	Count uint64 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *CharacterFrequency) Reset() {
+	*x = CharacterFrequency{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CharacterFrequency) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CharacterFrequency) ProtoMessage() {}
+
+func (x *CharacterFrequency) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CharacterFrequency.ProtoReflect.Descriptor instead.
+func (*CharacterFrequency) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CharacterFrequency) GetCharacter() string {
+	if x != nil {
+		return x.Character
+	}
+	return ""
+}
+
+func (x *CharacterFrequency) GetValue() uint32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *CharacterFrequency) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// The request message containing the texts of a batch
+type CountCharactersBatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Items []*CountCharactersBatchItem `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+}
+
+func (x *CountCharactersBatchRequest) Reset() {
+	*x = CountCharactersBatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountCharactersBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountCharactersBatchRequest) ProtoMessage() {}
+
+func (x *CountCharactersBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountCharactersBatchRequest.ProtoReflect.Descriptor instead.
+func (*CountCharactersBatchRequest) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CountCharactersBatchRequest) GetItems() []*CountCharactersBatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// A text of a batch with an ID chosen by the caller
+type CountCharactersBatchItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      string                  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Request *CountCharactersRequest `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+}
+
+func (x *CountCharactersBatchItem) Reset() {
+	*x = CountCharactersBatchItem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountCharactersBatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountCharactersBatchItem) ProtoMessage() {}
+
+func (x *CountCharactersBatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountCharactersBatchItem.ProtoReflect.Descriptor instead.
+func (*CountCharactersBatchItem) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CountCharactersBatchItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CountCharactersBatchItem) GetRequest() *CountCharactersRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+// The response message containing one result per item, in the order of the items
+type CountCharactersBatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*CountCharactersBatchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *CountCharactersBatchResponse) Reset() {
+	*x = CountCharactersBatchResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountCharactersBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountCharactersBatchResponse) ProtoMessage() {}
+
+func (x *CountCharactersBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountCharactersBatchResponse.ProtoReflect.Descriptor instead.
+func (*CountCharactersBatchResponse) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CountCharactersBatchResponse) GetResults() []*CountCharactersBatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// The result of a batch item, either its count or why counting failed
+type CountCharactersBatchResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The ID of the item
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are assignable to Result:
+	//	*CountCharactersBatchResult_Response
+	//	*CountCharactersBatchResult_Error
+	Result isCountCharactersBatchResult_Result `protobuf_oneof:"result"`
+}
+
+func (x *CountCharactersBatchResult) Reset() {
+	*x = CountCharactersBatchResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountCharactersBatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountCharactersBatchResult) ProtoMessage() {}
+
+func (x *CountCharactersBatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountCharactersBatchResult.ProtoReflect.Descriptor instead.
+func (*CountCharactersBatchResult) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CountCharactersBatchResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (m *CountCharactersBatchResult) GetResult() isCountCharactersBatchResult_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *CountCharactersBatchResult) GetResponse() *CountCharactersResponse {
+	if x, ok := x.GetResult().(*CountCharactersBatchResult_Response); ok {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *CountCharactersBatchResult) GetError() *CountError {
+	if x, ok := x.GetResult().(*CountCharactersBatchResult_Error); ok {
+		return x.Error
+	}
+	return nil
+}
+
+type isCountCharactersBatchResult_Result interface {
+	isCountCharactersBatchResult_Result()
+}
+
+type CountCharactersBatchResult_Response struct {
+	Response *CountCharactersResponse `protobuf:"bytes,2,opt,name=response,proto3,oneof"`
+}
+
+type CountCharactersBatchResult_Error struct {
+	Error *CountError `protobuf:"bytes,3,opt,name=error,proto3,oneof"`
+}
+
+func (*CountCharactersBatchResult_Response) isCountCharactersBatchResult_Result() {}
+
+func (*CountCharactersBatchResult_Error) isCountCharactersBatchResult_Result() {}
+
+// The error of a single batch item
+type CountError struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The gRPC status code, e.g. 3 for INVALID_ARGUMENT
+	Code    int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Details of the error like google.rpc.BadRequest
+	Details []*anypb.Any `protobuf:"bytes,3,rep,name=details,proto3" json:"details,omitempty"`
+}
+
+func (x *CountError) Reset() {
+	*x = CountError{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_character_counter_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CountError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountError) ProtoMessage() {}
+
+func (x *CountError) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountError.ProtoReflect.Descriptor instead.
+func (*CountError) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CountError) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *CountError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CountError) GetDetails() []*anypb.Any {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+// The request message containing the text to compute statistics of
+type CountTextRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Text string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	// The unit the longest line is measured in
+	Mode CountingMode `protobuf:"varint,2,opt,name=mode,proto3,enum=frontend.CountingMode" json:"mode,omitempty"`
 }
 
-func (x *CountCharactersRequest) Reset() {
-	*x = CountCharactersRequest{}
+func (x *CountTextRequest) Reset() {
+	*x = CountTextRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_character_counter_proto_msgTypes[0]
+		mi := &file_character_counter_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *CountCharactersRequest) String() string {
+func (x *CountTextRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CountCharactersRequest) ProtoMessage() {}
+func (*CountTextRequest) ProtoMessage() {}
 
-func (x *CountCharactersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_character_counter_proto_msgTypes[0]
+func (x *CountTextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,44 +803,68 @@ func (x *CountCharactersRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CountCharactersRequest.ProtoReflect.Descriptor instead.
-func (*CountCharactersRequest) Descriptor() ([]byte, []int) {
-	return file_character_counter_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use CountTextRequest.ProtoReflect.Descriptor instead.
+func (*CountTextRequest) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *CountCharactersRequest) GetText() string {
+func (x *CountTextRequest) GetText() string {
 	if x != nil {
 		return x.Text
 	}
 	return ""
 }
 
-// The response message containing the number characters
-type CountCharactersResponse struct {
+func (x *CountTextRequest) GetMode() CountingMode {
+	if x != nil {
+		return x.Mode
+	}
+	return CountingMode_COUNTING_MODE_UNSPECIFIED
+}
+
+// The response message containing the statistics of a text. Lines end at
+// mandatory line breaks like LF, CR LF or U+2028, words and sentences are
+// segmented according to Unicode Standard Annex #29.
+type CountTextResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Characters uint64 `protobuf:"varint,1,opt,name=characters,proto3" json:"characters,omitempty"`
+	Lines uint64 `protobuf:"varint,1,opt,name=lines,proto3" json:"lines,omitempty"`
+	// Lines containing only whitespace
+	BlankLines uint64 `protobuf:"varint,2,opt,name=blank_lines,json=blankLines,proto3" json:"blank_lines,omitempty"`
+	// Lines containing anything but whitespace
+	NonBlankLines uint64 `protobuf:"varint,3,opt,name=non_blank_lines,json=nonBlankLines,proto3" json:"non_blank_lines,omitempty"`
+	// The length of the longest line without its line break
+	LongestLine uint64 `protobuf:"varint,4,opt,name=longest_line,json=longestLine,proto3" json:"longest_line,omitempty"`
+	// Words containing at least one letter or number
+	Words     uint64 `protobuf:"varint,5,opt,name=words,proto3" json:"words,omitempty"`
+	Sentences uint64 `protobuf:"varint,6,opt,name=sentences,proto3" json:"sentences,omitempty"`
+	// Runs of consecutive whitespace characters, including line breaks
+	WhitespaceRuns uint64 `protobuf:"varint,7,opt,name=whitespace_runs,json=whitespaceRuns,proto3" json:"whitespace_runs,omitempty"`
+	// The response value configured for the server
+	Value string `protobuf:"bytes,8,opt,name=value,proto3" json:"value,omitempty"`
+	// The unit the longest line was measured in
+	Mode CountingMode `protobuf:"varint,9,opt,name=mode,proto3,enum=frontend.CountingMode" json:"mode,omitempty"`
 }
 
-func (x *CountCharactersResponse) Reset() {
-	*x = CountCharactersResponse{}
+func (x *CountTextResponse) Reset() {
+	*x = CountTextResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_character_counter_proto_msgTypes[1]
+		mi := &file_character_counter_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *CountCharactersResponse) String() string {
+func (x *CountTextResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CountCharactersResponse) ProtoMessage() {}
+func (*CountTextResponse) ProtoMessage() {}
 
-func (x *CountCharactersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_character_counter_proto_msgTypes[1]
+func (x *CountTextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_character_counter_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -104,38 +875,236 @@ func (x *CountCharactersResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CountCharactersResponse.ProtoReflect.Descriptor instead.
-func (*CountCharactersResponse) Descriptor() ([]byte, []int) {
-	return file_character_counter_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use CountTextResponse.ProtoReflect.Descriptor instead.
+func (*CountTextResponse) Descriptor() ([]byte, []int) {
+	return file_character_counter_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *CountCharactersResponse) GetCharacters() uint64 {
+func (x *CountTextResponse) GetLines() uint64 {
 	if x != nil {
-		return x.Characters
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *CountTextResponse) GetBlankLines() uint64 {
+	if x != nil {
+		return x.BlankLines
+	}
+	return 0
+}
+
+func (x *CountTextResponse) GetNonBlankLines() uint64 {
+	if x != nil {
+		return x.NonBlankLines
+	}
+	return 0
+}
+
+func (x *CountTextResponse) GetLongestLine() uint64 {
+	if x != nil {
+		return x.LongestLine
+	}
+	return 0
+}
+
+func (x *CountTextResponse) GetWords() uint64 {
+	if x != nil {
+		return x.Words
 	}
 	return 0
 }
 
+func (x *CountTextResponse) GetSentences() uint64 {
+	if x != nil {
+		return x.Sentences
+	}
+	return 0
+}
+
+func (x *CountTextResponse) GetWhitespaceRuns() uint64 {
+	if x != nil {
+		return x.WhitespaceRuns
+	}
+	return 0
+}
+
+func (x *CountTextResponse) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *CountTextResponse) GetMode() CountingMode {
+	if x != nil {
+		return x.Mode
+	}
+	return CountingMode_COUNTING_MODE_UNSPECIFIED
+}
+
 var File_character_counter_proto protoreflect.FileDescriptor
 
 var file_character_counter_proto_rawDesc = []byte{
 	0x0a, 0x17, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x2d, 0x63, 0x6f, 0x75, 0x6e,
 	0x74, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x66, 0x72, 0x6f, 0x6e, 0x74,
-	0x65, 0x6e, 0x64, 0x22, 0x2c, 0x0a, 0x16, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72,
-	0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a,
-	0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78,
-	0x74, 0x22, 0x39, 0x0a, 0x17, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63,
-	0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a,
-	0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x0a, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x32, 0x6c, 0x0a, 0x10,
-	0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72,
-	0x12, 0x58, 0x0a, 0x0f, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74,
-	0x65, 0x72, 0x73, 0x12, 0x20, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x43,
-	0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64,
+	0x65, 0x6e, 0x64, 0x1a, 0x19, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x61, 0x6e, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xdd,
+	0x01, 0x0a, 0x16, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65,
+	0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x2a, 0x0a,
+	0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x66, 0x72,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x69, 0x6e, 0x67, 0x4d,
+	0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x68, 0x69, 0x73,
+	0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x68, 0x69,
+	0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x12, 0x26, 0x0a, 0x0f, 0x68, 0x69, 0x73, 0x74, 0x6f,
+	0x67, 0x72, 0x61, 0x6d, 0x5f, 0x74, 0x6f, 0x70, 0x5f, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0d, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x54, 0x6f, 0x70, 0x4b, 0x12,
+	0x3d, 0x0a, 0x0d, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x64, 0x2e, 0x4e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x0d, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x56,
+	0x0a, 0x14, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72,
+	0x73, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x2a, 0x0a, 0x04, 0x6d, 0x6f,
+	0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x69, 0x6e, 0x67, 0x4d, 0x6f, 0x64, 0x65,
+	0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x22, 0xdc, 0x02, 0x0a, 0x17, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65,
+	0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x2a, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x69, 0x6e, 0x67, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04,
+	0x6d, 0x6f, 0x64, 0x65, 0x12, 0x2f, 0x0a, 0x13, 0x64, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x63, 0x74,
+	0x5f, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x12, 0x64, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x63, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61,
+	0x63, 0x74, 0x65, 0x72, 0x73, 0x12, 0x3a, 0x0a, 0x09, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72,
+	0x61, 0x6d, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x64, 0x2e, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x46, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x52, 0x09, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61,
+	0x6d, 0x12, 0x33, 0x0a, 0x15, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x5f,
+	0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x14, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x43, 0x68, 0x61, 0x72,
+	0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x12, 0x3d, 0x0a, 0x0d, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c,
+	0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e,
+	0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x4e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69,
+	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0d, 0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x5e, 0x0a, 0x12, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74,
+	0x65, 0x72, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x63,
+	0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x57, 0x0a, 0x1b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68,
+	0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x38, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x42, 0x61,
+	0x74, 0x63, 0x68, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x22, 0x66,
+	0x0a, 0x18, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72,
+	0x73, 0x42, 0x61, 0x74, 0x63, 0x68, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x3a, 0x0a, 0x07, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x66, 0x72,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72,
+	0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x5e, 0x0a, 0x1c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43,
+	0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3e, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65,
+	0x72, 0x73, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0xa5, 0x01, 0x0a, 0x1a, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x3f, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65,
+	0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x08, 0x72, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64,
+	0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x6a,
+	0x0a, 0x0a, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x64, 0x65,
+	0x74, 0x61, 0x69, 0x6c, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e,
+	0x79, 0x52, 0x07, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x22, 0x52, 0x0a, 0x10, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65,
+	0x78, 0x74, 0x12, 0x2a, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x16, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x69, 0x6e, 0x67, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x22, 0xb4,
+	0x02, 0x0a, 0x11, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x62, 0x6c,
+	0x61, 0x6e, 0x6b, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0a, 0x62, 0x6c, 0x61, 0x6e, 0x6b, 0x4c, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e,
+	0x6f, 0x6e, 0x5f, 0x62, 0x6c, 0x61, 0x6e, 0x6b, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x6e, 0x6f, 0x6e, 0x42, 0x6c, 0x61, 0x6e, 0x6b, 0x4c, 0x69,
+	0x6e, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x5f, 0x6c,
+	0x69, 0x6e, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x6c, 0x6f, 0x6e, 0x67, 0x65,
+	0x73, 0x74, 0x4c, 0x69, 0x6e, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x1c, 0x0a, 0x09,
+	0x73, 0x65, 0x6e, 0x74, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x09, 0x73, 0x65, 0x6e, 0x74, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x77, 0x68,
+	0x69, 0x74, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x5f, 0x72, 0x75, 0x6e, 0x73, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0e, 0x77, 0x68, 0x69, 0x74, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x52,
+	0x75, 0x6e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x2a, 0x0a, 0x04, 0x6d, 0x6f, 0x64,
+	0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x69, 0x6e, 0x67, 0x4d, 0x6f, 0x64, 0x65, 0x52,
+	0x04, 0x6d, 0x6f, 0x64, 0x65, 0x2a, 0xa6, 0x01, 0x0a, 0x0c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x69,
+	0x6e, 0x67, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1d, 0x0a, 0x19, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x49,
+	0x4e, 0x47, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x49, 0x4e,
+	0x47, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x42, 0x59, 0x54, 0x45, 0x53, 0x10, 0x01, 0x12, 0x1d,
+	0x0a, 0x19, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x49, 0x4e, 0x47, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f,
+	0x43, 0x4f, 0x44, 0x45, 0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x53, 0x10, 0x02, 0x12, 0x1b, 0x0a,
+	0x17, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x49, 0x4e, 0x47, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x47,
+	0x52, 0x41, 0x50, 0x48, 0x45, 0x4d, 0x45, 0x53, 0x10, 0x03, 0x12, 0x22, 0x0a, 0x1e, 0x43, 0x4f,
+	0x55, 0x4e, 0x54, 0x49, 0x4e, 0x47, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x54, 0x46, 0x31,
+	0x36, 0x5f, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x49, 0x54, 0x53, 0x10, 0x04, 0x2a, 0x8c,
+	0x01, 0x0a, 0x0d, 0x4e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x1d, 0x0a, 0x19, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x49, 0x5a, 0x41, 0x54, 0x49, 0x4f,
+	0x4e, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
+	0x15, 0x0a, 0x11, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x49, 0x5a, 0x41, 0x54, 0x49, 0x4f, 0x4e,
+	0x5f, 0x4e, 0x46, 0x43, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c,
+	0x49, 0x5a, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4e, 0x46, 0x44, 0x10, 0x02, 0x12, 0x16, 0x0a,
+	0x12, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x49, 0x5a, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4e,
+	0x46, 0x4b, 0x43, 0x10, 0x03, 0x12, 0x16, 0x0a, 0x12, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x49,
+	0x5a, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4e, 0x46, 0x4b, 0x44, 0x10, 0x04, 0x32, 0xfd, 0x02,
+	0x0a, 0x10, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x65, 0x72, 0x12, 0x58, 0x0a, 0x0f, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61,
+	0x63, 0x74, 0x65, 0x72, 0x73, 0x12, 0x20, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64,
+	0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65,
+	0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5e, 0x0a, 0x15,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x1e, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64,
 	0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x04, 0x5a, 0x02, 0x2e, 0x2f,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x21, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64,
+	0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x12, 0x67, 0x0a, 0x14,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x12, 0x25, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x66, 0x72,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x43, 0x68, 0x61, 0x72,
+	0x61, 0x63, 0x74, 0x65, 0x72, 0x73, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x09, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54, 0x65,
+	0x78, 0x74, 0x12, 0x1a, 0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b,
+	0x2e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x54,
+	0x65, 0x78, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x2f, 0x5a,
+	0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x6f, 0x6e, 0x61,
+	0x73, 0x32, 0x37, 0x2f, 0x72, 0x61, 0x6d, 0x70, 0x2d, 0x75, 0x70, 0x2d, 0x6b, 0x38, 0x73, 0x2d,
+	0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -150,19 +1119,52 @@ func file_character_counter_proto_rawDescGZIP() []byte {
 	return file_character_counter_proto_rawDescData
 }
 
-var file_character_counter_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_character_counter_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_character_counter_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_character_counter_proto_goTypes = []interface{}{
-	(*CountCharactersRequest)(nil),  // 0: frontend.CountCharactersRequest
-	(*CountCharactersResponse)(nil), // 1: frontend.CountCharactersResponse
+	(CountingMode)(0),                    // 0: frontend.CountingMode
+	(Normalization)(0),                   // 1: frontend.Normalization
+	(*CountCharactersRequest)(nil),       // 2: frontend.CountCharactersRequest
+	(*CountCharactersChunk)(nil),         // 3: frontend.CountCharactersChunk
+	(*CountCharactersResponse)(nil),      // 4: frontend.CountCharactersResponse
+	(*CharacterFrequency)(nil),           // 5: frontend.CharacterFrequency
+	(*CountCharactersBatchRequest)(nil),  // 6: frontend.CountCharactersBatchRequest
+	(*CountCharactersBatchItem)(nil),     // 7: frontend.CountCharactersBatchItem
+	(*CountCharactersBatchResponse)(nil), // 8: frontend.CountCharactersBatchResponse
+	(*CountCharactersBatchResult)(nil),   // 9: frontend.CountCharactersBatchResult
+	(*CountError)(nil),                   // 10: frontend.CountError
+	(*CountTextRequest)(nil),             // 11: frontend.CountTextRequest
+	(*CountTextResponse)(nil),            // 12: frontend.CountTextResponse
+	(*anypb.Any)(nil),                    // 13: google.protobuf.Any
 }
 var file_character_counter_proto_depIdxs = []int32{
-	0, // 0: frontend.CharacterCounter.CountCharacters:input_type -> frontend.CountCharactersRequest
-	1, // 1: frontend.CharacterCounter.CountCharacters:output_type -> frontend.CountCharactersResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: frontend.CountCharactersRequest.mode:type_name -> frontend.CountingMode
+	1,  // 1: frontend.CountCharactersRequest.normalization:type_name -> frontend.Normalization
+	0,  // 2: frontend.CountCharactersChunk.mode:type_name -> frontend.CountingMode
+	0,  // 3: frontend.CountCharactersResponse.mode:type_name -> frontend.CountingMode
+	5,  // 4: frontend.CountCharactersResponse.histogram:type_name -> frontend.CharacterFrequency
+	1,  // 5: frontend.CountCharactersResponse.normalization:type_name -> frontend.Normalization
+	7,  // 6: frontend.CountCharactersBatchRequest.items:type_name -> frontend.CountCharactersBatchItem
+	2,  // 7: frontend.CountCharactersBatchItem.request:type_name -> frontend.CountCharactersRequest
+	9,  // 8: frontend.CountCharactersBatchResponse.results:type_name -> frontend.CountCharactersBatchResult
+	4,  // 9: frontend.CountCharactersBatchResult.response:type_name -> frontend.CountCharactersResponse
+	10, // 10: frontend.CountCharactersBatchResult.error:type_name -> frontend.CountError
+	13, // 11: frontend.CountError.details:type_name -> google.protobuf.Any
+	0,  // 12: frontend.CountTextRequest.mode:type_name -> frontend.CountingMode
+	0,  // 13: frontend.CountTextResponse.mode:type_name -> frontend.CountingMode
+	2,  // 14: frontend.CharacterCounter.CountCharacters:input_type -> frontend.CountCharactersRequest
+	3,  // 15: frontend.CharacterCounter.CountCharactersStream:input_type -> frontend.CountCharactersChunk
+	6,  // 16: frontend.CharacterCounter.CountCharactersBatch:input_type -> frontend.CountCharactersBatchRequest
+	11, // 17: frontend.CharacterCounter.CountText:input_type -> frontend.CountTextRequest
+	4,  // 18: frontend.CharacterCounter.CountCharacters:output_type -> frontend.CountCharactersResponse
+	4,  // 19: frontend.CharacterCounter.CountCharactersStream:output_type -> frontend.CountCharactersResponse
+	8,  // 20: frontend.CharacterCounter.CountCharactersBatch:output_type -> frontend.CountCharactersBatchResponse
+	12, // 21: frontend.CharacterCounter.CountText:output_type -> frontend.CountTextResponse
+	18, // [18:22] is the sub-list for method output_type
+	14, // [14:18] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_character_counter_proto_init() }
@@ -184,6 +1186,18 @@ func file_character_counter_proto_init() {
 			}
 		}
 		file_character_counter_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountCharactersChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_character_counter_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*CountCharactersResponse); i {
 			case 0:
 				return &v.state
@@ -195,19 +1209,120 @@ func file_character_counter_proto_init() {
 				return nil
 			}
 		}
+		file_character_counter_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CharacterFrequency); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_character_counter_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountCharactersBatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_character_counter_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountCharactersBatchItem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_character_counter_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountCharactersBatchResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_character_counter_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountCharactersBatchResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_character_counter_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountError); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_character_counter_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountTextRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_character_counter_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CountTextResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_character_counter_proto_msgTypes[7].OneofWrappers = []interface{}{
+		(*CountCharactersBatchResult_Response)(nil),
+		(*CountCharactersBatchResult_Error)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_character_counter_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      2,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_character_counter_proto_goTypes,
 		DependencyIndexes: file_character_counter_proto_depIdxs,
+		EnumInfos:         file_character_counter_proto_enumTypes,
 		MessageInfos:      file_character_counter_proto_msgTypes,
 	}.Build()
 	File_character_counter_proto = out.File
@@ -4,7 +4,7 @@
 // - protoc             v3.12.4
 // source: character-counter.proto
 
-package __
+package proto
 
 import (
 	context "context"
@@ -23,6 +23,14 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CharacterCounterClient interface {
 	CountCharacters(ctx context.Context, in *CountCharactersRequest, opts ...grpc.CallOption) (*CountCharactersResponse, error)
+	// Counts the characters of a text sent in chunks and returns the total
+	// once the client closes the stream
+	CountCharactersStream(ctx context.Context, opts ...grpc.CallOption) (CharacterCounter_CountCharactersStreamClient, error)
+	// Counts the characters of many texts in one call. Every item gets its
+	// own result, so a single invalid item does not fail the whole batch
+	CountCharactersBatch(ctx context.Context, in *CountCharactersBatchRequest, opts ...grpc.CallOption) (*CountCharactersBatchResponse, error)
+	// Returns statistics of a text like lines, words and sentences
+	CountText(ctx context.Context, in *CountTextRequest, opts ...grpc.CallOption) (*CountTextResponse, error)
 }
 
 type characterCounterClient struct {
@@ -42,11 +50,71 @@ func (c *characterCounterClient) CountCharacters(ctx context.Context, in *CountC
 	return out, nil
 }
 
+func (c *characterCounterClient) CountCharactersStream(ctx context.Context, opts ...grpc.CallOption) (CharacterCounter_CountCharactersStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CharacterCounter_ServiceDesc.Streams[0], "/frontend.CharacterCounter/CountCharactersStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &characterCounterCountCharactersStreamClient{stream}
+	return x, nil
+}
+
+type CharacterCounter_CountCharactersStreamClient interface {
+	Send(*CountCharactersChunk) error
+	CloseAndRecv() (*CountCharactersResponse, error)
+	grpc.ClientStream
+}
+
+type characterCounterCountCharactersStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *characterCounterCountCharactersStreamClient) Send(m *CountCharactersChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *characterCounterCountCharactersStreamClient) CloseAndRecv() (*CountCharactersResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(CountCharactersResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *characterCounterClient) CountCharactersBatch(ctx context.Context, in *CountCharactersBatchRequest, opts ...grpc.CallOption) (*CountCharactersBatchResponse, error) {
+	out := new(CountCharactersBatchResponse)
+	err := c.cc.Invoke(ctx, "/frontend.CharacterCounter/CountCharactersBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *characterCounterClient) CountText(ctx context.Context, in *CountTextRequest, opts ...grpc.CallOption) (*CountTextResponse, error) {
+	out := new(CountTextResponse)
+	err := c.cc.Invoke(ctx, "/frontend.CharacterCounter/CountText", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CharacterCounterServer is the server API for CharacterCounter service.
 // All implementations must embed UnimplementedCharacterCounterServer
 // for forward compatibility
 type CharacterCounterServer interface {
 	CountCharacters(context.Context, *CountCharactersRequest) (*CountCharactersResponse, error)
+	// Counts the characters of a text sent in chunks and returns the total
+	// once the client closes the stream
+	CountCharactersStream(CharacterCounter_CountCharactersStreamServer) error
+	// Counts the characters of many texts in one call. Every item gets its
+	// own result, so a single invalid item does not fail the whole batch
+	CountCharactersBatch(context.Context, *CountCharactersBatchRequest) (*CountCharactersBatchResponse, error)
+	// Returns statistics of a text like lines, words and sentences
+	CountText(context.Context, *CountTextRequest) (*CountTextResponse, error)
 	mustEmbedUnimplementedCharacterCounterServer()
 }
 
@@ -57,6 +125,15 @@ type UnimplementedCharacterCounterServer struct {
 func (UnimplementedCharacterCounterServer) CountCharacters(context.Context, *CountCharactersRequest) (*CountCharactersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CountCharacters not implemented")
 }
+func (UnimplementedCharacterCounterServer) CountCharactersStream(CharacterCounter_CountCharactersStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method CountCharactersStream not implemented")
+}
+func (UnimplementedCharacterCounterServer) CountCharactersBatch(context.Context, *CountCharactersBatchRequest) (*CountCharactersBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountCharactersBatch not implemented")
+}
+func (UnimplementedCharacterCounterServer) CountText(context.Context, *CountTextRequest) (*CountTextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountText not implemented")
+}
 func (UnimplementedCharacterCounterServer) mustEmbedUnimplementedCharacterCounterServer() {}
 
 // UnsafeCharacterCounterServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +165,68 @@ func _CharacterCounter_CountCharacters_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CharacterCounter_CountCharactersStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CharacterCounterServer).CountCharactersStream(&characterCounterCountCharactersStreamServer{stream})
+}
+
+type CharacterCounter_CountCharactersStreamServer interface {
+	SendAndClose(*CountCharactersResponse) error
+	Recv() (*CountCharactersChunk, error)
+	grpc.ServerStream
+}
+
+type characterCounterCountCharactersStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *characterCounterCountCharactersStreamServer) SendAndClose(m *CountCharactersResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *characterCounterCountCharactersStreamServer) Recv() (*CountCharactersChunk, error) {
+	m := new(CountCharactersChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _CharacterCounter_CountCharactersBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountCharactersBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CharacterCounterServer).CountCharactersBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/frontend.CharacterCounter/CountCharactersBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CharacterCounterServer).CountCharactersBatch(ctx, req.(*CountCharactersBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CharacterCounter_CountText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountTextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CharacterCounterServer).CountText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/frontend.CharacterCounter/CountText",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CharacterCounterServer).CountText(ctx, req.(*CountTextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CharacterCounter_ServiceDesc is the grpc.ServiceDesc for CharacterCounter service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,7 +238,21 @@ var CharacterCounter_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CountCharacters",
 			Handler:    _CharacterCounter_CountCharacters_Handler,
 		},
+		{
+			MethodName: "CountCharactersBatch",
+			Handler:    _CharacterCounter_CountCharactersBatch_Handler,
+		},
+		{
+			MethodName: "CountText",
+			Handler:    _CharacterCounter_CountText_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CountCharactersStream",
+			Handler:       _CharacterCounter_CountCharactersStream_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "character-counter.proto",
 }
@@ -0,0 +1,169 @@
+// Package auth authenticates clients of the character counter service with
+// bearer tokens.
+//
+// Clients send one of the configured keys in the authorization metadata,
+// "authorization: Bearer <key>", which the HTTP gateway passes on from the
+// Authorization header. Only the frontend.CharacterCounter service requires
+// authentication, health checks and reflection are always served. The name of
+// the accepted key, the name of its file, identifies the client to later
+// interceptors, see ClientFromContext.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+// authorizationHeader is the metadata key holding the bearer token.
+const authorizationHeader = "authorization"
+
+// clientContextKey is the context key of the name of the authenticated client.
+type clientContextKey struct{}
+
+// ClientFromContext returns the name of the key the client of ctx
+// authenticated with. It is only set if authentication is enabled.
+func ClientFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(clientContextKey{}).(string)
+	return name, ok
+}
+
+// key is an accepted key and the name of the client owning it.
+type key struct {
+	name  string
+	value []byte
+}
+
+// Authenticator checks the bearer tokens of requests against a set of keys.
+// The zero value is not usable, create an Authenticator with New.
+type Authenticator struct {
+	mu      sync.RWMutex
+	enabled bool
+	keys    []key
+}
+
+// New returns an Authenticator accepting the keys configured by cfg. If
+// authentication is disabled, it accepts all requests. If the keys cannot be
+// read, it rejects all requests until SetConfig succeeds.
+func New(cfg config.Auth) *Authenticator {
+	a := &Authenticator{}
+	if err := a.SetConfig(cfg); err != nil {
+		log.Printf("unable to load auth keys: %v", err)
+	}
+	return a
+}
+
+// SetConfig reads the keys configured by cfg and replaces the accepted keys
+// with them. If they cannot be read, the previous keys stay accepted, and
+// none if authentication was disabled before.
+func (a *Authenticator) SetConfig(cfg config.Auth) error {
+	var keys []key
+	if cfg.Enabled() {
+		byName, err := config.ReadKeys(cfg.KeysDir)
+		if err != nil {
+			a.mu.Lock()
+			a.enabled = true
+			a.mu.Unlock()
+			return err
+		}
+		if len(byName) == 0 {
+			log.Printf("no auth keys in %s, rejecting all requests", cfg.KeysDir)
+		}
+		for name, value := range byName {
+			keys = append(keys, key{name: name, value: []byte(value)})
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.enabled, a.keys = cfg.Enabled(), keys
+	return nil
+}
+
+// UnaryInterceptor returns an interceptor rejecting unauthenticated unary
+// requests. It should run before the rate limiter, which tells clients apart
+// by the name of their key.
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.check(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor returns an interceptor rejecting unauthenticated streams.
+// It should run before the rate limiter, like UnaryInterceptor.
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.check(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticatedStream is a server stream carrying the authenticated client in
+// its context.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// check returns an Unauthenticated error if method requires authentication
+// and ctx carries no accepted bearer token. Otherwise it returns ctx with the
+// name of the accepted key, if any.
+func (a *Authenticator) check(ctx context.Context, method string) (context.Context, error) {
+	if !strings.HasPrefix(method, "/"+pb.CharacterCounter_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+
+	a.mu.RLock()
+	enabled, keys := a.enabled, a.keys
+	a.mu.RUnlock()
+	if !enabled {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "malformed authorization, want Bearer token")
+	}
+	i := accepted(keys, token)
+	if i < 0 {
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	return context.WithValue(ctx, clientContextKey{}, keys[i].name), nil
+}
+
+// accepted returns the index of token in keys, or -1 if it is none of them.
+// Every key is compared in constant time, so the time taken does not reveal
+// how much of a key matched.
+func accepted(keys []key, token string) int {
+	found := -1
+	for i, k := range keys {
+		found = subtle.ConstantTimeSelect(subtle.ConstantTimeCompare(k.value, []byte(token)), i, found)
+	}
+	return found
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+const countMethod = "/frontend.CharacterCounter/CountCharacters"
+
+func writeKeys(t *testing.T, dir string, keys map[string]string) {
+	t.Helper()
+	for name, key := range keys {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(key+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func withAuthorization(value string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", value))
+}
+
+func TestAuthenticator(t *testing.T) {
+	dir := t.TempDir()
+	writeKeys(t, dir, map[string]string{"alice": "key-a", "bob": "key-b"})
+	a := New(config.Auth{KeysDir: dir})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}{
+		{name: "valid key", ctx: withAuthorization("Bearer key-a"), method: countMethod, want: codes.OK},
+		{name: "other valid key", ctx: withAuthorization("bearer key-b"), method: countMethod, want: codes.OK},
+		{name: "invalid key", ctx: withAuthorization("Bearer key-c"), method: countMethod, want: codes.Unauthenticated},
+		{name: "prefix of key", ctx: withAuthorization("Bearer key"), method: countMethod, want: codes.Unauthenticated},
+		{name: "basic auth", ctx: withAuthorization("Basic a2V5LWE="), method: countMethod, want: codes.Unauthenticated},
+		{name: "missing", ctx: context.Background(), method: countMethod, want: codes.Unauthenticated},
+		{name: "health", ctx: context.Background(), method: "/grpc.health.v1.Health/Check", want: codes.OK},
+		{
+			name: "reflection", ctx: context.Background(),
+			method: "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", want: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		if _, err := a.check(tt.ctx, tt.method); status.Code(err) != tt.want {
+			t.Errorf("%s: code = %s, want %s", tt.name, status.Code(err), tt.want)
+		}
+	}
+}
+
+func TestClientFromContext(t *testing.T) {
+	dir := t.TempDir()
+	writeKeys(t, dir, map[string]string{"alice": "key-a", "bob": "key-b"})
+	a := New(config.Auth{KeysDir: dir})
+
+	ctx, err := a.check(withAuthorization("Bearer key-b"), countMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := ClientFromContext(ctx); !ok || name != "bob" {
+		t.Errorf("client = %q, %t, want bob", name, ok)
+	}
+
+	ctx, err = New(config.Auth{}).check(withAuthorization("Bearer key-b"), countMethod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := ClientFromContext(ctx); ok {
+		t.Errorf("client = %q with auth disabled, want none", name)
+	}
+}
+
+func TestAuthenticatorSetConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeKeys(t, dir, map[string]string{"alice": "key-a"})
+
+	a := New(config.Auth{})
+	if _, err := a.check(context.Background(), countMethod); err != nil {
+		t.Errorf("check() with auth disabled = %v, want nil", err)
+	}
+
+	if err := a.SetConfig(config.Auth{KeysDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	writeKeys(t, dir, map[string]string{"alice": "key-rotated"})
+	if _, err := a.check(withAuthorization("Bearer key-a"), countMethod); err != nil {
+		t.Errorf("check() before reload = %v, want nil", err)
+	}
+	if err := a.SetConfig(config.Auth{KeysDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.check(withAuthorization("Bearer key-a"), countMethod); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("check() with replaced key = %v, want Unauthenticated", err)
+	}
+	if _, err := a.check(withAuthorization("Bearer key-rotated"), countMethod); err != nil {
+		t.Errorf("check() with rotated key = %v, want nil", err)
+	}
+}
+
+func TestNewUnreadableKeys(t *testing.T) {
+	a := New(config.Auth{KeysDir: filepath.Join(t.TempDir(), "missing")})
+	if _, err := a.check(context.Background(), countMethod); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("check() with unreadable keys = %v, want Unauthenticated", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+	"github.com/jonas27/ramp-up-k8s-operator/server/counter"
+)
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = 5 * time.Second
+
+func main() {
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatalf("unable to load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	srv := counter.NewServer(cfg)
+	if cfg.Revision != "" {
+		log.Printf("loaded config revision %s", cfg.Revision)
+		go config.Watch(ctx, os.Args[0], os.Args[1:], configPollInterval, cfg, srv.SetConfig)
+	}
+
+	if err := counter.Run(ctx, srv); err != nil {
+		log.Fatalf("problem running server: %v", err)
+	}
+}
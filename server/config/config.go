@@ -0,0 +1,384 @@
+// Package config loads the configuration of the character counter server.
+//
+// Settings are read from, in increasing order of precedence, the defaults,
+// a JSON config file, environment variables and command line flags. The
+// config file is the one the operator mounts from the CharacterCounter
+// ConfigMap.
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables overriding the config file.
+const (
+	EnvConfigFile     = "COUNTER_CONFIG"
+	EnvPort           = "COUNTER_PORT"
+	EnvHTTPPort       = "COUNTER_HTTP_PORT"
+	EnvMetricsPort    = "COUNTER_METRICS_PORT"
+	EnvHealthPort     = "COUNTER_HEALTH_PORT"
+	EnvResponseValue  = "COUNTER_RESPONSE_VALUE"
+	EnvMaxTextBytes   = "COUNTER_MAX_TEXT_BYTES"
+	EnvMaxStreamBytes = "COUNTER_MAX_STREAM_BYTES"
+	EnvMaxBatchItems  = "COUNTER_MAX_BATCH_ITEMS"
+)
+
+// Default ports of the server.
+const (
+	DefaultPort        = 50051
+	DefaultMetricsPort = 9090
+)
+
+// Default limits of the server.
+const (
+	DefaultMaxTextBytes   = 1 << 20
+	DefaultMaxStreamBytes = 1 << 30
+	DefaultMaxBatchItems  = 1000
+)
+
+// Config is the configuration of the character counter server.
+type Config struct {
+	// Port is the port the gRPC server listens on.
+	Port int `json:"port"`
+	// HTTPPort is the port the HTTP/JSON gateway listens on. 0 disables it.
+	HTTPPort int `json:"httpPort"`
+	// MetricsPort is the port Prometheus metrics are served on. 0 disables them.
+	MetricsPort int `json:"metricsPort"`
+	// HealthPort is the port the gRPC health service is additionally served
+	// on without TLS, for probes that cannot speak TLS. 0 disables it.
+	HealthPort int `json:"healthPort"`
+	// ResponseValue is returned with every response.
+	ResponseValue string `json:"responseValue"`
+
+	// MaxTextBytes is the maximum size of a text sent in a single message.
+	MaxTextBytes int `json:"maxTextBytes"`
+	// MaxStreamBytes is the maximum size of a text sent as stream of chunks.
+	MaxStreamBytes int `json:"maxStreamBytes"`
+	// MaxBatchItems is the maximum number of texts in a batch.
+	MaxBatchItems int `json:"maxBatchItems"`
+
+	// RateLimit limits the request rate of every client. It can only be set
+	// in the config file.
+	RateLimit RateLimit `json:"rateLimit"`
+
+	// TLS configures the certificates of the gRPC server and the HTTP gateway.
+	// It can only be set in the config file.
+	TLS TLS `json:"tls"`
+
+	// Auth configures the bearer tokens clients authenticate with. It can
+	// only be set in the config file.
+	Auth Auth `json:"auth"`
+
+	// Revision identifies the content of the config file the configuration
+	// was loaded from, together with the auth keys. It is empty if no config
+	// file is used.
+	Revision string `json:"-"`
+}
+
+// Keys rate limits are applied per.
+const (
+	// RateLimitKeyPeer limits every client address.
+	RateLimitKeyPeer = "peer"
+	// RateLimitKeyAPIKey limits every API key sent in the request metadata
+	// and its client address, or every auth key if authentication is enabled.
+	// Requests without API key are limited per client address.
+	RateLimitKeyAPIKey = "apiKey"
+)
+
+// DefaultAPIKeyHeader is the metadata key holding the API key of a request.
+const DefaultAPIKeyHeader = "x-api-key"
+
+// RateLimit configures token bucket rate limits.
+type RateLimit struct {
+	// RequestsPerSecond is the rate the bucket of a client is refilled at.
+	// 0 disables rate limiting.
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	// Burst is the size of the bucket of a client.
+	Burst int `json:"burst"`
+	// Key is what clients are told apart by, RateLimitKeyPeer or RateLimitKeyAPIKey.
+	Key string `json:"key"`
+	// APIKeyHeader is the metadata key holding the API key.
+	APIKeyHeader string `json:"apiKeyHeader"`
+}
+
+// Enabled reports whether r limits requests.
+func (r RateLimit) Enabled() bool {
+	return r.RequestsPerSecond > 0
+}
+
+// Client certificate policies of the TLS server.
+const (
+	// ClientAuthNone does not request client certificates.
+	ClientAuthNone = "none"
+	// ClientAuthOptional verifies client certificates if clients send one.
+	ClientAuthOptional = "optional"
+	// ClientAuthRequire rejects clients without a valid client certificate.
+	ClientAuthRequire = "require"
+)
+
+// TLS configures the serving certificate and the verification of client
+// certificates. The files are read again whenever they change.
+type TLS struct {
+	// CertFile is the PEM encoded serving certificate chain. Empty disables TLS.
+	CertFile string `json:"certFile"`
+	// KeyFile is the PEM encoded private key of the serving certificate.
+	KeyFile string `json:"keyFile"`
+	// ClientCAFile holds the PEM encoded CAs client certificates are verified against.
+	ClientCAFile string `json:"clientCAFile"`
+	// ClientAuth is the client certificate policy, ClientAuthNone,
+	// ClientAuthOptional or ClientAuthRequire.
+	ClientAuth string `json:"clientAuth"`
+}
+
+// Enabled reports whether t serves TLS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Auth configures bearer token authentication.
+type Auth struct {
+	// KeysDir is the directory holding the accepted keys, one per file named
+	// after the client owning it. Empty disables authentication.
+	KeysDir string `json:"keysDir"`
+}
+
+// Enabled reports whether a requires clients to authenticate.
+func (a Auth) Enabled() bool {
+	return a.KeysDir != ""
+}
+
+// ReadKeys returns the keys in the files of dir by file name. Hidden files,
+// like the data directories of mounted Secrets, are skipped.
+func ReadKeys(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read auth keys: %w", err)
+	}
+	keys := map[string]string{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		// Files of mounted Secrets are symlinks, so stat the target.
+		path := filepath.Join(dir, e.Name())
+		if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read auth key: %w", err)
+		}
+		if key := strings.TrimSpace(string(b)); key != "" {
+			keys[e.Name()] = key
+		}
+	}
+	return keys, nil
+}
+
+// Default returns the default configuration.
+func Default() Config {
+	return Config{
+		Port:           DefaultPort,
+		MetricsPort:    DefaultMetricsPort,
+		MaxTextBytes:   DefaultMaxTextBytes,
+		MaxStreamBytes: DefaultMaxStreamBytes,
+		MaxBatchItems:  DefaultMaxBatchItems,
+		RateLimit:      RateLimit{Key: RateLimitKeyPeer, APIKeyHeader: DefaultAPIKeyHeader},
+		TLS:            TLS{ClientAuth: ClientAuthNone},
+	}
+}
+
+// Validate checks that c can be served.
+func (c Config) Validate() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("port %d out of range", c.Port)
+	}
+	if c.HTTPPort < 0 || c.HTTPPort > 65535 {
+		return fmt.Errorf("HTTP port %d out of range", c.HTTPPort)
+	}
+	if c.HTTPPort == c.Port {
+		return fmt.Errorf("HTTP port %d equals the gRPC port", c.HTTPPort)
+	}
+	if c.MetricsPort < 0 || c.MetricsPort > 65535 {
+		return fmt.Errorf("metrics port %d out of range", c.MetricsPort)
+	}
+	if c.MetricsPort != 0 && (c.MetricsPort == c.Port || c.MetricsPort == c.HTTPPort) {
+		return fmt.Errorf("metrics port %d is already used", c.MetricsPort)
+	}
+	if c.HealthPort < 0 || c.HealthPort > 65535 {
+		return fmt.Errorf("health port %d out of range", c.HealthPort)
+	}
+	if c.HealthPort != 0 && (c.HealthPort == c.Port || c.HealthPort == c.HTTPPort || c.HealthPort == c.MetricsPort) {
+		return fmt.Errorf("health port %d is already used", c.HealthPort)
+	}
+	if c.MaxTextBytes < 1 || c.MaxStreamBytes < 1 || c.MaxBatchItems < 1 {
+		return fmt.Errorf("limits must be positive")
+	}
+	if rl := c.RateLimit; rl.Enabled() {
+		if rl.Burst < 1 {
+			return fmt.Errorf("rate limit burst must be positive")
+		}
+		if rl.Key != RateLimitKeyPeer && rl.Key != RateLimitKeyAPIKey {
+			return fmt.Errorf("unknown rate limit key %q", rl.Key)
+		}
+	}
+	if t := c.TLS; t.Enabled() {
+		if t.KeyFile == "" {
+			return fmt.Errorf("TLS key file missing")
+		}
+		switch t.ClientAuth {
+		case ClientAuthNone:
+		case ClientAuthOptional, ClientAuthRequire:
+			if t.ClientCAFile == "" {
+				return fmt.Errorf("TLS client CA file missing for client auth %q", t.ClientAuth)
+			}
+		default:
+			return fmt.Errorf("unknown TLS client auth %q", t.ClientAuth)
+		}
+	}
+	return nil
+}
+
+// intSetting is an integer setting of Config that can be overridden by an
+// environment variable and a flag.
+type intSetting struct {
+	flag, env, usage string
+	field            func(*Config) *int
+}
+
+var intSettings = []intSetting{
+	{"port", EnvPort, "The port the gRPC server listens on.", func(c *Config) *int { return &c.Port }},
+	{"http-port", EnvHTTPPort, "The port the HTTP/JSON gateway listens on, 0 disables it.",
+		func(c *Config) *int { return &c.HTTPPort }},
+	{"metrics-port", EnvMetricsPort, "The port metrics are served on, 0 disables them.",
+		func(c *Config) *int { return &c.MetricsPort }},
+	{"health-port", EnvHealthPort, "The port the gRPC health service is served on without TLS, 0 disables it.",
+		func(c *Config) *int { return &c.HealthPort }},
+	{"max-text-bytes", EnvMaxTextBytes, "The maximum size of a text sent in a single message.",
+		func(c *Config) *int { return &c.MaxTextBytes }},
+	{"max-stream-bytes", EnvMaxStreamBytes, "The maximum size of a text sent as stream of chunks.",
+		func(c *Config) *int { return &c.MaxStreamBytes }},
+	{"max-batch-items", EnvMaxBatchItems, "The maximum number of texts in a batch.",
+		func(c *Config) *int { return &c.MaxBatchItems }},
+}
+
+// Load parses args with the given program name and returns the resulting
+// configuration.
+func Load(name string, args []string) (Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("config", os.Getenv(EnvConfigFile), "Path of the JSON config file.")
+	defaults := Default()
+	intFlags := make([]*int, len(intSettings))
+	for i, is := range intSettings {
+		intFlags[i] = fs.Int(is.flag, *is.field(&defaults), is.usage)
+	}
+	responseValue := fs.String("response-value", "", "The value returned with every response.")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	if *file != "" {
+		var err error
+		if cfg, err = ReadFile(*file); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, is := range intSettings {
+		if v, ok := os.LookupEnv(is.env); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("parse %s: %w", is.env, err)
+			}
+			*is.field(&cfg) = n
+		}
+	}
+	if v, ok := os.LookupEnv(EnvResponseValue); ok {
+		cfg.ResponseValue = v
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "response-value" {
+			cfg.ResponseValue = *responseValue
+		}
+		for i, is := range intSettings {
+			if f.Name == is.flag {
+				*is.field(&cfg) = *intFlags[i]
+			}
+		}
+	})
+
+	return cfg, cfg.Validate()
+}
+
+// ReadFile reads the JSON config file at path. Fields missing in the file
+// keep their default values. The revision also covers the auth keys.
+func ReadFile(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config file: %w", err)
+	}
+	cfg := Default()
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	h := sha256.New()
+	h.Write(b)
+	if cfg.Auth.Enabled() {
+		// Changed keys are reloaded like a changed config file.
+		keys, err := ReadKeys(cfg.Auth.KeysDir)
+		if err != nil {
+			return Config{}, err
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(h, "\x00%s\x00%s", name, keys[name])
+		}
+	}
+	cfg.Revision = hex.EncodeToString(h.Sum(nil))[:12]
+	return cfg, nil
+}
+
+// Watch loads the configuration like Load every interval until ctx is done
+// and calls fn with it whenever the revision of the config file differs from
+// the one of current. Mounted ConfigMaps are updated by swapping symlinks, so
+// the file is polled instead of watched for events. A configuration that
+// fails to load is logged and skipped.
+func Watch(ctx context.Context, name string, args []string, interval time.Duration, current Config, fn func(Config)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cfg, err := Load(name, args)
+		if err != nil {
+			log.Printf("reload config: %v", err)
+			continue
+		}
+		if cfg.Revision != current.Revision {
+			current = cfg
+			fn(cfg)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"port": 6000, "responseValue": "from file", "maxTextBytes": 100}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The first 12 hex digits of the SHA-256 of the file content.
+	const revision = "7f4b128d40ba"
+
+	// want returns the default config changed by fn.
+	want := func(fn func(*Config)) Config {
+		cfg := Default()
+		fn(&cfg)
+		return cfg
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want Config
+	}{
+		{name: "defaults", want: Default()},
+		{
+			name: "file",
+			args: []string{"--config", path},
+			want: want(func(c *Config) {
+				c.Port, c.ResponseValue, c.MaxTextBytes, c.Revision = 6000, "from file", 100, revision
+			}),
+		},
+		{
+			name: "env overrides file",
+			env:  map[string]string{EnvConfigFile: path, EnvResponseValue: "from env", EnvMaxTextBytes: "200"},
+			want: want(func(c *Config) {
+				c.Port, c.ResponseValue, c.MaxTextBytes, c.Revision = 6000, "from env", 200, revision
+			}),
+		},
+		{
+			name: "flags override env",
+			env:  map[string]string{EnvPort: "7000", EnvHTTPPort: "7080", EnvMetricsPort: "7090", EnvMaxBatchItems: "7"},
+			args: []string{
+				"--config", path, "--port", "8000", "--http-port", "8080", "--metrics-port", "8090",
+				"--response-value", "from flag", "--max-batch-items", "8",
+			},
+			want: want(func(c *Config) {
+				c.Port, c.HTTPPort, c.MetricsPort, c.ResponseValue = 8000, 8080, 8090, "from flag"
+				c.MaxTextBytes, c.MaxBatchItems, c.Revision = 100, 8, revision
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got, err := Load("server", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidPort(t *testing.T) {
+	if _, err := Load("server", []string{"--port", "70000"}); err == nil {
+		t.Error("Load() accepted port 70000")
+	}
+	if _, err := Load("server", []string{"--http-port", "50051"}); err == nil {
+		t.Error("Load() accepted the gRPC port as HTTP port")
+	}
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	tests := []struct {
+		rateLimit RateLimit
+		wantErr   bool
+	}{
+		{rateLimit: RateLimit{}},
+		{rateLimit: RateLimit{RequestsPerSecond: 1, Burst: 1, Key: RateLimitKeyAPIKey}},
+		{rateLimit: RateLimit{RequestsPerSecond: 1, Key: RateLimitKeyPeer}, wantErr: true},
+		{rateLimit: RateLimit{RequestsPerSecond: 1, Burst: 1, Key: "user"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := Default()
+		cfg.RateLimit = tt.rateLimit
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate() with rate limit %+v = %v, want error %t", tt.rateLimit, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"responseValue": "first"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"--config", path}
+	current, err := Load("server", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan Config)
+	go Watch(ctx, "server", args, time.Millisecond, current, func(cfg Config) { reloaded <- cfg })
+
+	if err := os.WriteFile(path, []byte(`{"responseValue": "second"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cfg := <-reloaded:
+		if cfg.ResponseValue != "second" || cfg.Revision == current.Revision {
+			t.Errorf("reloaded config = %+v, want response value %q and a new revision", cfg, "second")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	tests := []struct {
+		tls     TLS
+		wantErr bool
+	}{
+		{tls: TLS{ClientAuth: ClientAuthNone}},
+		{tls: TLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: ClientAuthNone}},
+		{tls: TLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt", ClientAuth: ClientAuthRequire}},
+		{tls: TLS{CertFile: "tls.crt", ClientAuth: ClientAuthNone}, wantErr: true},
+		{tls: TLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: ClientAuthOptional}, wantErr: true},
+		{tls: TLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: "always"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := Default()
+		cfg.TLS = tt.tls
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate() with TLS %+v = %v, want error %t", tt.tls, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReadFileAuthKeys(t *testing.T) {
+	dir := t.TempDir()
+	keysDir := filepath.Join(dir, "keys")
+	// Mounted Secrets hold their data in hidden directories.
+	if err := os.MkdirAll(filepath.Join(keysDir, "..data"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(keysDir, "alice"), []byte("key-a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"auth": {"keysDir": "`+keysDir+`"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := ReadKeys(keysDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys["alice"] != "key-a" {
+		t.Errorf("ReadKeys() = %v, want alice's key", keys)
+	}
+
+	before, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(keysDir, "alice"), []byte("key-b\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	after, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Revision == after.Revision {
+		t.Error("revision did not change with the auth keys")
+	}
+}
@@ -0,0 +1,52 @@
+package counter
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+// resolveMode returns the counting mode used for the requested mode.
+// COUNTING_MODE_UNSPECIFIED counts code points.
+func resolveMode(mode pb.CountingMode) (pb.CountingMode, error) {
+	switch mode {
+	case pb.CountingMode_COUNTING_MODE_UNSPECIFIED:
+		return pb.CountingMode_COUNTING_MODE_CODE_POINTS, nil
+	case pb.CountingMode_COUNTING_MODE_BYTES,
+		pb.CountingMode_COUNTING_MODE_CODE_POINTS,
+		pb.CountingMode_COUNTING_MODE_GRAPHEMES,
+		pb.CountingMode_COUNTING_MODE_UTF16_CODE_UNITS:
+		return mode, nil
+	default:
+		return mode, fmt.Errorf("unknown counting mode %d", mode)
+	}
+}
+
+// count returns the number of characters in text counted in the resolved mode.
+func count(text string, mode pb.CountingMode) uint64 {
+	switch mode {
+	case pb.CountingMode_COUNTING_MODE_BYTES:
+		return uint64(len(text))
+	case pb.CountingMode_COUNTING_MODE_GRAPHEMES:
+		return uint64(uniseg.GraphemeClusterCount(text))
+	case pb.CountingMode_COUNTING_MODE_UTF16_CODE_UNITS:
+		var n uint64
+		for _, r := range text {
+			n += uint64(utf16Len(r))
+		}
+		return n
+	default:
+		return uint64(utf8.RuneCountInString(text))
+	}
+}
+
+// utf16Len returns the number of UTF-16 code units encoding r.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,44 @@
+package counter
+
+import (
+	"testing"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+func TestCount(t *testing.T) {
+	const (
+		bytes     = pb.CountingMode_COUNTING_MODE_BYTES
+		runes     = pb.CountingMode_COUNTING_MODE_CODE_POINTS
+		graphemes = pb.CountingMode_COUNTING_MODE_GRAPHEMES
+		utf16     = pb.CountingMode_COUNTING_MODE_UTF16_CODE_UNITS
+	)
+
+	tests := []struct {
+		name string
+		text string
+		want map[pb.CountingMode]uint64
+	}{
+		{name: "ascii", text: "hello", want: map[pb.CountingMode]uint64{bytes: 5, runes: 5, graphemes: 5, utf16: 5}},
+		{name: "combining mark", text: "e\u0301", want: map[pb.CountingMode]uint64{bytes: 3, runes: 2, graphemes: 1, utf16: 2}},
+		{name: "emoji", text: "😀", want: map[pb.CountingMode]uint64{bytes: 4, runes: 1, graphemes: 1, utf16: 2}},
+		{name: "family emoji", text: "\U0001F468\u200d\U0001F469\u200d\U0001F467", want: map[pb.CountingMode]uint64{bytes: 18, runes: 5, graphemes: 1, utf16: 8}},
+		{name: "flag", text: "\U0001F1E9\U0001F1EA", want: map[pb.CountingMode]uint64{bytes: 8, runes: 2, graphemes: 1, utf16: 4}},
+	}
+	for _, tt := range tests {
+		for mode, want := range tt.want {
+			if got := count(tt.text, mode); got != want {
+				t.Errorf("%s: count(%q, %s) = %d, want %d", tt.name, tt.text, mode, got, want)
+			}
+		}
+	}
+}
+
+func TestResolveMode(t *testing.T) {
+	if got, err := resolveMode(pb.CountingMode_COUNTING_MODE_UNSPECIFIED); err != nil || got != pb.CountingMode_COUNTING_MODE_CODE_POINTS {
+		t.Errorf("resolveMode(UNSPECIFIED) = %s, %v, want CODE_POINTS", got, err)
+	}
+	if _, err := resolveMode(pb.CountingMode(42)); err == nil {
+		t.Error("resolveMode(42) accepted an unknown mode")
+	}
+}
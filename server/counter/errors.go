@@ -0,0 +1,51 @@
+package counter
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+// invalidArgument returns an InvalidArgument error with a google.rpc.BadRequest
+// detail describing why the request field is invalid.
+func invalidArgument(field, description string) error {
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("invalid %s: %s", field, description))
+	if withDetails, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+	}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// quotaExceeded returns a ResourceExhausted error with a google.rpc.QuotaFailure
+// detail describing which limit the request field exceeded.
+func quotaExceeded(field, description string) error {
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("%s too large: %s", field, description))
+	if withDetails, err := st.WithDetails(&errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{{Subject: field, Description: description}},
+	}); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// validateText checks that text of the request field is not empty, within the
+// size limit of cfg and valid UTF-8.
+func validateText(field, text string, cfg config.Config) error {
+	switch {
+	case text == "":
+		return invalidArgument(field, "must not be empty")
+	case len(text) > cfg.MaxTextBytes:
+		return quotaExceeded(field, fmt.Sprintf("%d bytes exceed the limit of %d bytes", len(text), cfg.MaxTextBytes))
+	case !utf8.ValidString(text):
+		return invalidArgument(field, "must be valid UTF-8")
+	default:
+		return nil
+	}
+}
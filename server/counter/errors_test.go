@@ -0,0 +1,67 @@
+package counter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+func TestValidation(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxTextBytes, cfg.MaxBatchItems = 5, 1
+	s := NewServer(cfg)
+	ctx := context.Background()
+
+	_, emptyErr := s.CountCharacters(ctx, &pb.CountCharactersRequest{})
+	_, tooLargeErr := s.CountCharacters(ctx, &pb.CountCharactersRequest{Text: strings.Repeat("a", 6)})
+	_, invalidUTF8Err := s.CountCharacters(ctx, &pb.CountCharactersRequest{Text: "a\xff"})
+	_, modeErr := s.CountCharacters(ctx, &pb.CountCharactersRequest{Text: "a", Mode: 42})
+	_, emptyTextErr := s.CountText(ctx, &pb.CountTextRequest{})
+	_, emptyBatchErr := s.CountCharactersBatch(ctx, &pb.CountCharactersBatchRequest{})
+	_, largeBatchErr := s.CountCharactersBatch(ctx, &pb.CountCharactersBatchRequest{
+		Items: []*pb.CountCharactersBatchItem{{}, {}},
+	})
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  codes.Code
+		wantField string
+	}{
+		{name: "empty text", err: emptyErr, wantCode: codes.InvalidArgument, wantField: "text"},
+		{name: "text too large", err: tooLargeErr, wantCode: codes.ResourceExhausted, wantField: "text"},
+		{name: "invalid UTF-8", err: invalidUTF8Err, wantCode: codes.InvalidArgument, wantField: "text"},
+		{name: "unknown mode", err: modeErr, wantCode: codes.InvalidArgument, wantField: "mode"},
+		{name: "empty CountText", err: emptyTextErr, wantCode: codes.InvalidArgument, wantField: "text"},
+		{name: "empty batch", err: emptyBatchErr, wantCode: codes.InvalidArgument, wantField: "items"},
+		{name: "batch too large", err: largeBatchErr, wantCode: codes.ResourceExhausted, wantField: "items"},
+	}
+	for _, tt := range tests {
+		st := status.Convert(tt.err)
+		if st.Code() != tt.wantCode {
+			t.Errorf("%s: code = %s, want %s", tt.name, st.Code(), tt.wantCode)
+			continue
+		}
+		if len(st.Details()) != 1 {
+			t.Errorf("%s: got %d details, want 1", tt.name, len(st.Details()))
+			continue
+		}
+		var field string
+		switch d := st.Details()[0].(type) {
+		case *errdetails.BadRequest:
+			field = d.GetFieldViolations()[0].GetField()
+		case *errdetails.QuotaFailure:
+			field = d.GetViolations()[0].GetSubject()
+		}
+		if field != tt.wantField {
+			t.Errorf("%s: violated field = %q, want %q", tt.name, field, tt.wantField)
+		}
+	}
+}
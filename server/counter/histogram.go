@@ -0,0 +1,75 @@
+package counter
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+// character identifies a character of a histogram. Graphemes are only
+// identified by their text, all other units by their value.
+type character struct {
+	text  string
+	value uint32
+}
+
+// histogram returns the frequency of every character of text counted in the
+// resolved mode, sorted by descending count, and the number of distinct
+// characters. topK limits the returned frequencies if greater than zero.
+func histogram(text string, mode pb.CountingMode, topK int) ([]*pb.CharacterFrequency, uint64) {
+	counts := make(map[character]uint64)
+	switch mode {
+	case pb.CountingMode_COUNTING_MODE_BYTES:
+		for i := 0; i < len(text); i++ {
+			c := character{value: uint32(text[i])}
+			if text[i] < utf8.RuneSelf {
+				c.text = text[i : i+1]
+			}
+			counts[c]++
+		}
+	case pb.CountingMode_COUNTING_MODE_GRAPHEMES:
+		state := -1
+		for rest := text; len(rest) > 0; {
+			var cluster string
+			cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+			counts[character{text: cluster}]++
+		}
+	case pb.CountingMode_COUNTING_MODE_UTF16_CODE_UNITS:
+		for _, r := range text {
+			if utf16Len(r) == 1 {
+				counts[character{text: string(r), value: uint32(r)}]++
+				continue
+			}
+			r1, r2 := utf16.EncodeRune(r)
+			counts[character{value: uint32(r1)}]++
+			counts[character{value: uint32(r2)}]++
+		}
+	default:
+		for _, r := range text {
+			counts[character{text: string(r), value: uint32(r)}]++
+		}
+	}
+
+	freqs := make([]*pb.CharacterFrequency, 0, len(counts))
+	for c, n := range counts {
+		freqs = append(freqs, &pb.CharacterFrequency{Character: c.text, Value: c.value, Count: n})
+	}
+	sort.Slice(freqs, func(i, j int) bool {
+		a, b := freqs[i], freqs[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Value != b.Value {
+			return a.Value < b.Value
+		}
+		return a.Character < b.Character
+	})
+	if topK > 0 && topK < len(freqs) {
+		freqs = freqs[:topK]
+	}
+	return freqs, uint64(len(counts))
+}
@@ -0,0 +1,76 @@
+package counter
+
+import (
+	"testing"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+func TestHistogram(t *testing.T) {
+	type freq struct {
+		character string
+		value     uint32
+		count     uint64
+	}
+
+	tests := []struct {
+		name         string
+		text         string
+		mode         pb.CountingMode
+		topK         int
+		want         []freq
+		wantDistinct uint64
+	}{
+		{
+			name:         "code points",
+			text:         "banana",
+			mode:         pb.CountingMode_COUNTING_MODE_CODE_POINTS,
+			want:         []freq{{"a", 'a', 3}, {"n", 'n', 2}, {"b", 'b', 1}},
+			wantDistinct: 3,
+		},
+		{
+			name:         "top k",
+			text:         "banana",
+			mode:         pb.CountingMode_COUNTING_MODE_CODE_POINTS,
+			topK:         1,
+			want:         []freq{{"a", 'a', 3}},
+			wantDistinct: 3,
+		},
+		{
+			name:         "bytes",
+			text:         "aé",
+			mode:         pb.CountingMode_COUNTING_MODE_BYTES,
+			want:         []freq{{"a", 0x61, 1}, {"", 0xa9, 1}, {"", 0xc3, 1}},
+			wantDistinct: 3,
+		},
+		{
+			name:         "graphemes",
+			text:         "éée",
+			mode:         pb.CountingMode_COUNTING_MODE_GRAPHEMES,
+			want:         []freq{{"é", 0, 2}, {"e", 0, 1}},
+			wantDistinct: 2,
+		},
+		{
+			name:         "utf16 surrogates",
+			text:         "😀a",
+			mode:         pb.CountingMode_COUNTING_MODE_UTF16_CODE_UNITS,
+			want:         []freq{{"a", 'a', 1}, {"", 0xd83d, 1}, {"", 0xde00, 1}},
+			wantDistinct: 3,
+		},
+	}
+	for _, tt := range tests {
+		got, distinct := histogram(tt.text, tt.mode, tt.topK)
+		if distinct != tt.wantDistinct {
+			t.Errorf("%s: distinct = %d, want %d", tt.name, distinct, tt.wantDistinct)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d frequencies, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i, w := range tt.want {
+			if g := got[i]; g.GetCharacter() != w.character || g.GetValue() != w.value || g.GetCount() != w.count {
+				t.Errorf("%s: frequency %d = %v, want %+v", tt.name, i, g, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package counter
+
+import (
+	"fmt"
+
+	"golang.org/x/text/unicode/norm"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+// normalize returns text in the requested normalization form.
+// NORMALIZATION_UNSPECIFIED returns text unchanged.
+func normalize(text string, n pb.Normalization) (string, error) {
+	switch n {
+	case pb.Normalization_NORMALIZATION_UNSPECIFIED:
+		return text, nil
+	case pb.Normalization_NORMALIZATION_NFC:
+		return norm.NFC.String(text), nil
+	case pb.Normalization_NORMALIZATION_NFD:
+		return norm.NFD.String(text), nil
+	case pb.Normalization_NORMALIZATION_NFKC:
+		return norm.NFKC.String(text), nil
+	case pb.Normalization_NORMALIZATION_NFKD:
+		return norm.NFKD.String(text), nil
+	default:
+		return "", fmt.Errorf("unknown normalization %d", n)
+	}
+}
@@ -0,0 +1,52 @@
+package counter
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+func TestCountCharactersNormalization(t *testing.T) {
+	s := newTestServer("")
+
+	const (
+		composed   = "caf\u00e9"
+		decomposed = "cafe\u0301"
+		ligature   = "\ufb01ne"
+	)
+	tests := []struct {
+		text           string
+		normalization  pb.Normalization
+		wantRaw        uint64
+		wantNormalized uint64
+	}{
+		{text: composed, normalization: pb.Normalization_NORMALIZATION_UNSPECIFIED, wantRaw: 4, wantNormalized: 0},
+		{text: decomposed, normalization: pb.Normalization_NORMALIZATION_NFC, wantRaw: 5, wantNormalized: 4},
+		{text: composed, normalization: pb.Normalization_NORMALIZATION_NFD, wantRaw: 4, wantNormalized: 5},
+		{text: ligature, normalization: pb.Normalization_NORMALIZATION_NFC, wantRaw: 3, wantNormalized: 3},
+		{text: ligature, normalization: pb.Normalization_NORMALIZATION_NFKC, wantRaw: 3, wantNormalized: 4},
+		{text: ligature + composed, normalization: pb.Normalization_NORMALIZATION_NFKD, wantRaw: 7, wantNormalized: 9},
+	}
+	for _, tt := range tests {
+		resp, err := s.CountCharacters(context.Background(), &pb.CountCharactersRequest{
+			Text:          tt.text,
+			Normalization: tt.normalization,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetCharacters() != tt.wantRaw || resp.GetNormalizedCharacters() != tt.wantNormalized {
+			t.Errorf("CountCharacters(%q, %s) = %d raw, %d normalized, want %d, %d", tt.text, tt.normalization,
+				resp.GetCharacters(), resp.GetNormalizedCharacters(), tt.wantRaw, tt.wantNormalized)
+		}
+	}
+
+	_, err := s.CountCharacters(context.Background(), &pb.CountCharactersRequest{Text: composed, Normalization: 42})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CountCharacters() with unknown normalization error = %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,384 @@
+// Package counter implements the frontend.CharacterCounter gRPC service.
+package counter
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+	"github.com/jonas27/ramp-up-k8s-operator/server/auth"
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+	"github.com/jonas27/ramp-up-k8s-operator/server/gateway"
+	"github.com/jonas27/ramp-up-k8s-operator/server/metrics"
+	"github.com/jonas27/ramp-up-k8s-operator/server/ratelimit"
+	"github.com/jonas27/ramp-up-k8s-operator/server/tlsconfig"
+)
+
+const (
+	// shutdownTimeout is how long Run waits for in-flight requests to finish
+	// before it closes all connections.
+	shutdownTimeout = 10 * time.Second
+	// readHeaderTimeout is how long the HTTP gateway waits for the headers
+	// of a request.
+	readHeaderTimeout = 10 * time.Second
+)
+
+// Server implements pb.CharacterCounterServer.
+type Server struct {
+	pb.UnimplementedCharacterCounterServer
+
+	cfg     atomic.Pointer[config.Config]
+	limiter *ratelimit.Limiter
+	auth    *auth.Authenticator
+}
+
+// NewServer returns a Server answering with the settings of cfg.
+func NewServer(cfg config.Config) *Server {
+	s := &Server{limiter: ratelimit.New(cfg.RateLimit), auth: auth.New(cfg.Auth)}
+	s.cfg.Store(&cfg)
+	metrics.SetConfigRevision(cfg.Revision)
+	return s
+}
+
+// Config returns the configuration s currently uses.
+func (s *Server) Config() config.Config {
+	return *s.cfg.Load()
+}
+
+// SetConfig atomically replaces the configuration of s with cfg. Requests
+// in flight finish with the previous configuration. The ports and TLS
+// settings cannot change without a restart and keep their previous values.
+func (s *Server) SetConfig(cfg config.Config) {
+	old := s.Config()
+	if cfg.Port != old.Port || cfg.HTTPPort != old.HTTPPort || cfg.MetricsPort != old.MetricsPort ||
+		cfg.HealthPort != old.HealthPort {
+		log.Print("ignoring changed ports until the next restart")
+		cfg.Port, cfg.HTTPPort, cfg.MetricsPort, cfg.HealthPort = old.Port, old.HTTPPort, old.MetricsPort, old.HealthPort
+	}
+	if cfg.TLS != old.TLS {
+		log.Print("ignoring changed TLS settings until the next restart")
+		cfg.TLS = old.TLS
+	}
+	s.cfg.Store(&cfg)
+	s.limiter.SetConfig(cfg.RateLimit)
+	if err := s.auth.SetConfig(cfg.Auth); err != nil {
+		log.Printf("unable to reload auth keys: %v", err)
+	}
+	metrics.SetConfigRevision(cfg.Revision)
+	metrics.ConfigReloads.Inc()
+	log.Printf("reloaded config revision %s", cfg.Revision)
+}
+
+// Register registers s on the gRPC service registrar r.
+func (s *Server) Register(r grpc.ServiceRegistrar) {
+	pb.RegisterCharacterCounterServer(r, s)
+}
+
+// CountCharacters returns the number of characters in the text of req,
+// counted in the unit requested by its mode. If requested, it also counts
+// the normalized text and returns the frequencies of the characters.
+func (s *Server) CountCharacters(_ context.Context, req *pb.CountCharactersRequest) (*pb.CountCharactersResponse, error) {
+	cfg := s.Config()
+	if err := validateText("text", req.GetText(), cfg); err != nil {
+		return nil, err
+	}
+	mode, err := resolveMode(req.GetMode())
+	if err != nil {
+		return nil, invalidArgument("mode", err.Error())
+	}
+	text, err := normalize(req.GetText(), req.GetNormalization())
+	if err != nil {
+		return nil, invalidArgument("normalization", err.Error())
+	}
+
+	resp := &pb.CountCharactersResponse{
+		Characters:    count(req.GetText(), mode),
+		Value:         cfg.ResponseValue,
+		Mode:          mode,
+		Normalization: req.GetNormalization(),
+	}
+	if req.GetNormalization() != pb.Normalization_NORMALIZATION_UNSPECIFIED {
+		resp.NormalizedCharacters = count(text, mode)
+	}
+	if req.GetHistogram() {
+		resp.Histogram, resp.DistinctCharacters = histogram(text, mode, int(req.GetHistogramTopK()))
+	}
+	return resp, nil
+}
+
+// CountCharactersStream returns the number of characters in the text sent
+// in chunks, counted in the unit requested by the mode of the first chunk.
+// Multi-byte sequences and grapheme clusters may span several chunks.
+func (s *Server) CountCharactersStream(stream pb.CharacterCounter_CountCharactersStreamServer) error {
+	cfg := s.Config()
+	var (
+		c    *streamCounter
+		size int
+	)
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			mode, err := resolveMode(chunk.GetMode())
+			if err != nil {
+				return invalidArgument("mode", err.Error())
+			}
+			c = newStreamCounter(mode)
+		}
+		if size += len(chunk.GetData()); size > cfg.MaxStreamBytes {
+			return quotaExceeded("data", fmt.Sprintf("more than %d bytes streamed", cfg.MaxStreamBytes))
+		}
+		if err := c.Write(chunk.GetData()); err != nil {
+			return invalidArgument("data", err.Error())
+		}
+	}
+	if size == 0 {
+		return invalidArgument("data", "must not be empty")
+	}
+
+	n, err := c.Close()
+	if err != nil {
+		return invalidArgument("data", err.Error())
+	}
+	return stream.SendAndClose(&pb.CountCharactersResponse{
+		Characters: n,
+		Value:      cfg.ResponseValue,
+		Mode:       c.mode,
+	})
+}
+
+// CountCharactersBatch counts the characters of every item of req like
+// CountCharacters. An item that cannot be counted gets an error result
+// instead of failing the whole batch.
+func (s *Server) CountCharactersBatch(ctx context.Context, req *pb.CountCharactersBatchRequest) (*pb.CountCharactersBatchResponse, error) {
+	switch n, limit := len(req.GetItems()), s.Config().MaxBatchItems; {
+	case n == 0:
+		return nil, invalidArgument("items", "must not be empty")
+	case n > limit:
+		return nil, quotaExceeded("items", fmt.Sprintf("%d items exceed the limit of %d items", n, limit))
+	}
+
+	results := make([]*pb.CountCharactersBatchResult, 0, len(req.GetItems()))
+	for _, item := range req.GetItems() {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		result := &pb.CountCharactersBatchResult{Id: item.GetId()}
+		resp, err := s.CountCharacters(ctx, item.GetRequest())
+		if err != nil {
+			st := status.Convert(err)
+			result.Result = &pb.CountCharactersBatchResult_Error{Error: &pb.CountError{
+				Code:    int32(st.Code()),
+				Message: st.Message(),
+				Details: st.Proto().GetDetails(),
+			}}
+		} else {
+			result.Result = &pb.CountCharactersBatchResult_Response{Response: resp}
+		}
+		results = append(results, result)
+	}
+	return &pb.CountCharactersBatchResponse{Results: results}, nil
+}
+
+// CountText returns statistics of the text of req like its lines, words and
+// sentences. The longest line is measured in the unit requested by its mode.
+func (s *Server) CountText(_ context.Context, req *pb.CountTextRequest) (*pb.CountTextResponse, error) {
+	cfg := s.Config()
+	if err := validateText("text", req.GetText(), cfg); err != nil {
+		return nil, err
+	}
+	mode, err := resolveMode(req.GetMode())
+	if err != nil {
+		return nil, invalidArgument("mode", err.Error())
+	}
+	resp := textStats(req.GetText(), mode)
+	resp.Value = cfg.ResponseValue
+	return resp, nil
+}
+
+// Run serves s until ctx is done, together with the gRPC health and
+// reflection services and, if their ports are configured, the HTTP/JSON
+// gateway, the metrics and the plaintext health service. Requests to s are
+// observed in the metrics, authenticated if configured and rate limited. If
+// TLS is configured, gRPC and the gateway are only served over TLS.
+//
+// When ctx is done, Run reports NOT_SERVING, stops accepting new requests and
+// waits for in-flight requests to finish before it returns.
+func Run(ctx context.Context, s *Server) error {
+	cfg := s.Config()
+	var tlsLoader *tlsconfig.Loader
+	if cfg.TLS.Enabled() {
+		var err error
+		if tlsLoader, err = tlsconfig.New(cfg.TLS); err != nil {
+			return err
+		}
+	}
+
+	var all []net.Listener
+	listen := func(port int) (net.Listener, error) {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			for _, l := range all {
+				l.Close()
+			}
+			return nil, fmt.Errorf("listen on port %d: %w", port, err)
+		}
+		all = append(all, lis)
+		return lis, nil
+	}
+
+	var (
+		lis listeners
+		err error
+	)
+	if lis.grpc, err = listen(cfg.Port); err != nil {
+		return err
+	}
+	for _, opt := range []struct {
+		port int
+		lis  *net.Listener
+	}{{cfg.HTTPPort, &lis.http}, {cfg.MetricsPort, &lis.metrics}, {cfg.HealthPort, &lis.health}} {
+		if opt.port == 0 {
+			continue
+		}
+		if *opt.lis, err = listen(opt.port); err != nil {
+			return err
+		}
+	}
+	return serve(ctx, s, lis, tlsLoader)
+}
+
+// listeners are the listeners serve serves on. All but grpc are nil if
+// disabled.
+type listeners struct {
+	grpc, http, metrics, health net.Listener
+}
+
+// serve implements Run on lis. The gRPC server and the HTTP gateway serve
+// TLS with the certificates of tlsLoader unless it is nil.
+func serve(ctx context.Context, s *Server, lis listeners, tlsLoader *tlsconfig.Loader) error {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(metrics.UnaryInterceptor(), s.auth.UnaryInterceptor(), s.limiter.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamInterceptor(), s.auth.StreamInterceptor(), s.limiter.StreamInterceptor()),
+	}
+	if tlsLoader != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsLoader.Config("h2"))))
+	}
+	srv := grpc.NewServer(opts...)
+	s.Register(srv)
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(pb.CharacterCounter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthSrv)
+	reflection.Register(srv)
+
+	grpcSrvs := []*grpc.Server{srv}
+	errCh := make(chan error, 4)
+	serveGRPC := func(srv *grpc.Server, lis net.Listener, name string) {
+		go func() {
+			log.Printf("serving %s on %s", name, lis.Addr())
+			errCh <- srv.Serve(lis)
+		}()
+	}
+	serveGRPC(srv, lis.grpc, "gRPC")
+	if lis.health != nil {
+		// Kubelet gRPC probes cannot speak TLS, so the health service is
+		// also served on its own plaintext port.
+		healthOnly := grpc.NewServer()
+		healthpb.RegisterHealthServer(healthOnly, healthSrv)
+		grpcSrvs = append(grpcSrvs, healthOnly)
+		serveGRPC(healthOnly, lis.health, "gRPC health")
+	}
+
+	var httpSrvs []*http.Server
+	serveHTTP := func(lis net.Listener, h http.Handler, name string, tlsCfg *tls.Config) {
+		if lis == nil {
+			return
+		}
+		httpSrv := &http.Server{Handler: h, ReadHeaderTimeout: readHeaderTimeout, TLSConfig: tlsCfg}
+		httpSrvs = append(httpSrvs, httpSrv)
+		go func() {
+			log.Printf("serving %s on %s", name, lis.Addr())
+			if tlsCfg != nil {
+				// The certificates are provided by the TLS config.
+				errCh <- httpSrv.ServeTLS(lis, "", "")
+				return
+			}
+			errCh <- httpSrv.Serve(lis)
+		}()
+	}
+	var gatewayTLS *tls.Config
+	if tlsLoader != nil {
+		gatewayTLS = tlsLoader.Config("h2", "http/1.1")
+	}
+	serveHTTP(lis.http, gateway.NewHandler(s, metrics.UnaryInterceptor(), s.auth.UnaryInterceptor(),
+		s.limiter.UnaryInterceptor()), "HTTP gateway", gatewayTLS)
+	serveHTTP(lis.metrics, metrics.Handler(), "metrics", nil)
+
+	select {
+	case err := <-errCh:
+		for _, srv := range grpcSrvs {
+			srv.Stop()
+		}
+		for _, httpSrv := range httpSrvs {
+			httpSrv.Close()
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Print("shutting down")
+	// Report NOT_SERVING for all services, so clients and readiness probes
+	// stop sending new requests while in-flight requests finish.
+	healthSrv.Shutdown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, httpSrv := range httpSrvs {
+		if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+			log.Print("graceful HTTP shutdown timed out, closing connections")
+			httpSrv.Close()
+		}
+	}
+	stopped := make(chan struct{})
+	go func() {
+		for _, srv := range grpcSrvs {
+			srv.GracefulStop()
+		}
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		log.Print("graceful shutdown timed out, closing connections")
+		for _, srv := range grpcSrvs {
+			srv.Stop()
+		}
+	}
+
+	for i := 0; i < len(grpcSrvs)+len(httpSrvs); i++ {
+		if err := <-errCh; err != nil && !errors.Is(err, grpc.ErrServerStopped) && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package counter
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+// newTestServer returns a Server with the default limits answering with responseValue.
+func newTestServer(responseValue string) *Server {
+	cfg := config.Default()
+	cfg.ResponseValue = responseValue
+	return NewServer(cfg)
+}
+
+func TestCountCharacters(t *testing.T) {
+	s := newTestServer("hello")
+
+	tests := []struct {
+		text string
+		want uint64
+	}{
+		{text: "abc", want: 3},
+		{text: "héllo", want: 5},
+		{text: "日本語", want: 3},
+	}
+	for _, tt := range tests {
+		resp, err := s.CountCharacters(context.Background(), &pb.CountCharactersRequest{Text: tt.text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetCharacters() != tt.want {
+			t.Errorf("CountCharacters(%q) = %d, want %d", tt.text, resp.GetCharacters(), tt.want)
+		}
+		if resp.GetMode() != pb.CountingMode_COUNTING_MODE_CODE_POINTS {
+			t.Errorf("CountCharacters(%q) mode = %s, want CODE_POINTS", tt.text, resp.GetMode())
+		}
+		if resp.GetValue() != "hello" {
+			t.Errorf("CountCharacters(%q) value = %q, want %q", tt.text, resp.GetValue(), "hello")
+		}
+	}
+}
+
+func TestCountCharactersBatch(t *testing.T) {
+	s := newTestServer("hello")
+
+	resp, err := s.CountCharactersBatch(context.Background(), &pb.CountCharactersBatchRequest{
+		Items: []*pb.CountCharactersBatchItem{
+			{Id: "a", Request: &pb.CountCharactersRequest{Text: "héllo"}},
+			{Id: "b", Request: &pb.CountCharactersRequest{Text: "héllo", Mode: 42}},
+			{Id: "c", Request: &pb.CountCharactersRequest{Text: "héllo", Mode: pb.CountingMode_COUNTING_MODE_BYTES}},
+			{Id: "d", Request: &pb.CountCharactersRequest{}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := resp.GetResults()
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4", len(results))
+	}
+	if results[0].GetId() != "a" || results[0].GetResponse().GetCharacters() != 5 {
+		t.Errorf("result a = %v, want 5 characters", results[0])
+	}
+	if results[1].GetId() != "b" || results[1].GetError().GetCode() != int32(codes.InvalidArgument) {
+		t.Errorf("result b = %v, want InvalidArgument error", results[1])
+	}
+	if results[2].GetId() != "c" || results[2].GetResponse().GetCharacters() != 6 {
+		t.Errorf("result c = %v, want 6 characters", results[2])
+	}
+	if err := results[3].GetError(); err.GetCode() != int32(codes.InvalidArgument) || len(err.GetDetails()) != 1 {
+		t.Errorf("result d = %v, want InvalidArgument error with details", results[3])
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Run(ctx, NewServer(config.Config{})) }()
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() = %v, want nil", err)
+	}
+}
+
+func TestServeHealth(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serve(ctx, NewServer(config.Config{}), listeners{grpc: lis}, nil) }()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	watch, err := healthpb.NewHealthClient(conn).Watch(watchCtx,
+		&healthpb.HealthCheckRequest{Service: pb.CharacterCounter_ServiceDesc.ServiceName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := watch.Recv(); err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("health status = %v, %v, want SERVING", resp.GetStatus(), err)
+	}
+
+	cancel()
+	if resp, err := watch.Recv(); err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("health status after shutdown = %v, %v, want NOT_SERVING", resp.GetStatus(), err)
+	}
+	stopWatch()
+	if err := <-done; err != nil {
+		t.Errorf("serve() = %v, want nil", err)
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	s := newTestServer("old")
+	cfg := s.Config()
+	cfg.Port, cfg.ResponseValue, cfg.Revision = 6000, "new", "2"
+	s.SetConfig(cfg)
+
+	got := s.Config()
+	if got.ResponseValue != "new" || got.Revision != "2" {
+		t.Errorf("config after reload = %+v, want response value %q and revision %q", got, "new", "2")
+	}
+	if got.Port != 50051 {
+		t.Errorf("port after reload = %d, want unchanged 50051", got.Port)
+	}
+
+	resp, err := s.CountCharacters(context.Background(), &pb.CountCharactersRequest{Text: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetValue() != "new" {
+		t.Errorf("value after reload = %q, want %q", resp.GetValue(), "new")
+	}
+}
@@ -0,0 +1,136 @@
+package counter
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+var (
+	errInvalidUTF8    = errors.New("text is not valid UTF-8")
+	errIncompleteUTF8 = errors.New("text ends with an incomplete UTF-8 sequence")
+)
+
+// maxGraphemeBytes is the size from which a grapheme cluster that is still
+// being continued is counted, so a client sending one endless cluster, e.g.
+// a letter followed by combining marks, cannot make the server hold and
+// rescan it for the rest of the stream. Regular clusters are far smaller.
+const maxGraphemeBytes = 1 << 10
+
+// streamCounter counts the characters of a text written in chunks. Chunks
+// may split multi-byte sequences and grapheme clusters, so it keeps the
+// bytes that can still be continued by the next chunk in pending.
+type streamCounter struct {
+	mode    pb.CountingMode
+	pending []byte
+	n       uint64
+
+	// state is the grapheme segmentation state at the start of pending.
+	state int
+	// continued is set if the first cluster of pending continues an
+	// overlong cluster that was already counted.
+	continued bool
+}
+
+// newStreamCounter returns a streamCounter counting in the resolved mode.
+func newStreamCounter(mode pb.CountingMode) *streamCounter {
+	return &streamCounter{mode: mode, state: -1}
+}
+
+// Write counts the complete characters of the text written so far.
+func (c *streamCounter) Write(p []byte) error {
+	c.pending = append(c.pending, p...)
+
+	// Hold back a trailing multi-byte sequence missing its continuation bytes.
+	end := len(c.pending)
+	if start := lastRuneStart(c.pending); start >= 0 && !utf8.FullRune(c.pending[start:]) {
+		end = start
+	}
+	complete := c.pending[:end]
+	if !utf8.Valid(complete) {
+		return errInvalidUTF8
+	}
+
+	if c.mode != pb.CountingMode_COUNTING_MODE_GRAPHEMES {
+		c.n += count(string(complete), c.mode)
+		c.pending = append(c.pending[:0], c.pending[end:]...)
+		return nil
+	}
+
+	// The last grapheme cluster may be continued by the next chunk, e.g. by
+	// a combining mark or a zero width joiner.
+	start := c.countGraphemes(complete)
+	held := len(complete) - start
+	c.pending = append(c.pending[:0], c.pending[start:]...)
+	if held > maxGraphemeBytes {
+		// Count the overlong cluster now and only keep its last rune to
+		// segment the continuation.
+		c.countCluster()
+		c.continued = true
+		last := lastRuneStart(c.pending[:held])
+		c.pending = append(c.pending[:0], c.pending[last:]...)
+		c.state = -1
+	}
+	return nil
+}
+
+// Close counts the remaining text and returns the total.
+func (c *streamCounter) Close() (uint64, error) {
+	if !utf8.Valid(c.pending) {
+		if utf8.FullRune(c.pending[lastRuneStart(c.pending):]) {
+			return 0, errInvalidUTF8
+		}
+		return 0, errIncompleteUTF8
+	}
+	if c.mode == pb.CountingMode_COUNTING_MODE_GRAPHEMES {
+		for rest := c.pending; len(rest) > 0; {
+			_, rest, _, c.state = uniseg.FirstGraphemeCluster(rest, c.state)
+			c.countCluster()
+		}
+	} else {
+		c.n += count(string(c.pending), c.mode)
+	}
+	c.pending = nil
+	return c.n, nil
+}
+
+// countGraphemes counts the grapheme clusters of the valid UTF-8 text p but
+// the last one, which may be continued by the next chunk, and returns the
+// index of its first byte. The segmentation state is carried over from the
+// previous chunk, so every byte is only segmented once unless held back.
+func (c *streamCounter) countGraphemes(p []byte) int {
+	start := 0
+	for {
+		cluster, rest, _, state := uniseg.FirstGraphemeCluster(p[start:], c.state)
+		if len(rest) == 0 {
+			return start
+		}
+		c.countCluster()
+		start += len(cluster)
+		c.state = state
+	}
+}
+
+// countCluster counts a grapheme cluster, unless it continues an overlong
+// cluster that was already counted.
+func (c *streamCounter) countCluster() {
+	if c.continued {
+		c.continued = false
+		return
+	}
+	c.n++
+}
+
+// lastRuneStart returns the index of the first byte of the last UTF-8
+// sequence in p, which is at most utf8.UTFMax bytes long, or -1 if p is empty.
+func lastRuneStart(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			return i
+		}
+	}
+	return len(p) - 1
+}
@@ -0,0 +1,118 @@
+package counter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+func TestStreamCounterSplits(t *testing.T) {
+	modes := []pb.CountingMode{
+		pb.CountingMode_COUNTING_MODE_BYTES,
+		pb.CountingMode_COUNTING_MODE_CODE_POINTS,
+		pb.CountingMode_COUNTING_MODE_GRAPHEMES,
+		pb.CountingMode_COUNTING_MODE_UTF16_CODE_UNITS,
+	}
+	texts := []string{
+		"héllo wörld",
+		"éé",
+		"a😀b",
+		"\U0001F468\u200d\U0001F469\u200d\U0001F467 family",
+		"\U0001F1E9\U0001F1EA\U0001F1EB\U0001F1F7",
+	}
+	for _, text := range texts {
+		for _, mode := range modes {
+			want := count(text, mode)
+			// Split the text at every pair of byte offsets.
+			for i := 0; i <= len(text); i++ {
+				for j := i; j <= len(text); j++ {
+					c := newStreamCounter(mode)
+					for _, chunk := range []string{text[:i], text[i:j], text[j:]} {
+						if err := c.Write([]byte(chunk)); err != nil {
+							t.Fatalf("Write(%q) in mode %s: %v", chunk, mode, err)
+						}
+					}
+					got, err := c.Close()
+					if err != nil {
+						t.Fatalf("Close() of %q split at %d,%d in mode %s: %v", text, i, j, mode, err)
+					}
+					if got != want {
+						t.Errorf("count of %q split at %d,%d in mode %s = %d, want %d", text, i, j, mode, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestStreamCounterInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   error
+	}{
+		{name: "invalid byte", chunks: []string{"a\xffb"}, want: errInvalidUTF8},
+		{name: "invalid continuation", chunks: []string{"\xe6", "\x97a"}, want: errInvalidUTF8},
+		{name: "truncated", chunks: []string{"a", "\xe6\x97"}, want: errIncompleteUTF8},
+	}
+	for _, tt := range tests {
+		c := newStreamCounter(pb.CountingMode_COUNTING_MODE_CODE_POINTS)
+		var err error
+		for _, chunk := range tt.chunks {
+			if err = c.Write([]byte(chunk)); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			_, err = c.Close()
+		}
+		if err != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// streamCombining streams a letter followed by n combining acute accents in
+// chunks of chunkSize bytes and returns the counter after closing it.
+func streamCombining(t *testing.T, n, chunkSize int) (*streamCounter, uint64) {
+	t.Helper()
+	text := []byte("a" + strings.Repeat("́", n))
+	c := newStreamCounter(pb.CountingMode_COUNTING_MODE_GRAPHEMES)
+	for len(text) > 0 {
+		chunk := text
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		text = text[len(chunk):]
+		if err := c.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+		// The held back bytes are bounded, so every chunk takes the same time.
+		if len(c.pending) > maxGraphemeBytes+chunkSize {
+			t.Fatalf("%d bytes held back, want at most %d", len(c.pending), maxGraphemeBytes+chunkSize)
+		}
+	}
+	got, err := c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, got
+}
+
+func TestStreamCounterOverlongGrapheme(t *testing.T) {
+	if _, got := streamCombining(t, 1<<19, 61); got != 1 {
+		t.Errorf("count of an endless cluster = %d, want 1", got)
+	}
+
+	// Quadratic segmentation takes 16 times as long for 4 times the text.
+	start := time.Now()
+	streamCombining(t, 1<<18, 64)
+	small := time.Since(start)
+	start = time.Now()
+	streamCombining(t, 1<<20, 64)
+	if large := time.Since(start); large > 10*small {
+		t.Errorf("streaming 4 times the text took %s instead of %s, want linear time", large, small)
+	}
+}
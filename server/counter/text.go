@@ -0,0 +1,95 @@
+package counter
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+// textStats returns the line, word, sentence and whitespace statistics of
+// text. The longest line is measured in the resolved mode. All statistics
+// are computed in a single pass over the grapheme clusters of text.
+func textStats(text string, mode pb.CountingMode) *pb.CountTextResponse {
+	stats := &pb.CountTextResponse{Mode: mode}
+	var (
+		lineLen         uint64
+		lineBlank       = true
+		inSpace         bool
+		wordHasText     bool
+		sentenceHasText bool
+	)
+	state := -1
+	for rest := text; len(rest) > 0; {
+		var cluster string
+		var boundaries int
+		cluster, rest, boundaries, state = uniseg.StepString(rest, state)
+		r, _ := utf8.DecodeRuneInString(cluster)
+		space := unicode.IsSpace(r)
+
+		if space && !inSpace {
+			stats.WhitespaceRuns++
+		}
+		inSpace = space
+
+		if !space {
+			lineBlank = false
+			sentenceHasText = true
+		}
+		if !isLineBreak(r) {
+			lineLen += count(cluster, mode)
+		}
+		if boundaries&uniseg.MaskLine == uniseg.LineMustBreak {
+			stats.Lines++
+			if lineBlank {
+				stats.BlankLines++
+			} else {
+				stats.NonBlankLines++
+			}
+			if lineLen > stats.LongestLine {
+				stats.LongestLine = lineLen
+			}
+			lineLen, lineBlank = 0, true
+		}
+
+		if !wordHasText {
+			wordHasText = hasLetterOrNumber(cluster)
+		}
+		if boundaries&uniseg.MaskWord != 0 || len(rest) == 0 {
+			if wordHasText {
+				stats.Words++
+			}
+			wordHasText = false
+		}
+
+		if boundaries&uniseg.MaskSentence != 0 || len(rest) == 0 {
+			if sentenceHasText {
+				stats.Sentences++
+			}
+			sentenceHasText = false
+		}
+	}
+	return stats
+}
+
+// isLineBreak reports whether r is a mandatory line break.
+func isLineBreak(r rune) bool {
+	switch r {
+	case '\n', '\v', '\f', '\r', '\u0085', '\u2028', '\u2029':
+		return true
+	default:
+		return false
+	}
+}
+
+// hasLetterOrNumber reports whether s contains a letter or a number.
+func hasLetterOrNumber(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package counter
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+func TestTextStats(t *testing.T) {
+	const runes = pb.CountingMode_COUNTING_MODE_CODE_POINTS
+
+	tests := []struct {
+		name string
+		text string
+		mode pb.CountingMode
+		want *pb.CountTextResponse
+	}{
+		{name: "empty", text: "", mode: runes, want: &pb.CountTextResponse{}},
+		{
+			name: "single line",
+			text: "Hello, wörld!",
+			mode: runes,
+			want: &pb.CountTextResponse{Lines: 1, NonBlankLines: 1, LongestLine: 13, Words: 2, Sentences: 1, WhitespaceRuns: 1},
+		},
+		{
+			name: "blank lines",
+			text: "one two\n\n  \r\nthree. Four?\n",
+			mode: runes,
+			want: &pb.CountTextResponse{
+				Lines: 4, BlankLines: 2, NonBlankLines: 2, LongestLine: 12,
+				Words: 4, Sentences: 3, WhitespaceRuns: 4,
+			},
+		},
+		{
+			name: "graphemes",
+			text: "e\u0301te\u0301\u2028ab",
+			mode: pb.CountingMode_COUNTING_MODE_GRAPHEMES,
+			want: &pb.CountTextResponse{Lines: 2, NonBlankLines: 2, LongestLine: 3, Words: 2, Sentences: 2, WhitespaceRuns: 1},
+		},
+	}
+	for _, tt := range tests {
+		got := textStats(tt.text, tt.mode)
+		tt.want.Mode = tt.mode
+		if !proto.Equal(got, tt.want) {
+			t.Errorf("%s: textStats(%q) = %v, want %v", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package gateway exposes the frontend.CharacterCounter service over
+// HTTP/JSON for clients that cannot speak gRPC.
+//
+// Every unary RPC is served as a POST route taking and returning the JSON
+// mapping of its protobuf messages:
+//
+//	POST /v1/count        CountCharacters
+//	POST /v1/count/batch  CountCharactersBatch
+//	POST /v1/text         CountText
+//
+// Errors are returned as {"code": <gRPC code>, "message": "..."} with the
+// HTTP status corresponding to the gRPC status code.
+//
+// Requests pass the same unary interceptors as gRPC requests. The request
+// headers are passed to them as incoming metadata and the client address as
+// peer.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+// maxBodyBytes is the maximum size of a request body, matching the default
+// maximum message size of the gRPC server.
+const maxBodyBytes = 4 << 20
+
+// NewHandler returns a handler serving the RPCs of srv over HTTP/JSON. The
+// interceptors are called on every request in the given order.
+func NewHandler(srv pb.CharacterCounterServer, interceptors ...grpc.UnaryServerInterceptor) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/count", route(srv, "CountCharacters", srv.CountCharacters, interceptors))
+	mux.Handle("/v1/count/batch", route(srv, "CountCharactersBatch", srv.CountCharactersBatch, interceptors))
+	mux.Handle("/v1/text", route(srv, "CountText", srv.CountText, interceptors))
+	return mux
+}
+
+// route returns a handler decoding the JSON request body into a Req, calling
+// rpc with it through the interceptors and encoding the response or error as
+// JSON. method is the name of rpc in the service.
+func route[Req any, Resp proto.Message, PReq interface {
+	*Req
+	proto.Message
+}](srv pb.CharacterCounterServer, method string, rpc func(context.Context, PReq) (Resp, error),
+	interceptors []grpc.UnaryServerInterceptor,
+) http.Handler {
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + pb.CharacterCounter_ServiceDesc.ServiceName + "/" + method,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return rpc(ctx, req.(PReq))
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, req any) (any, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, status.New(codes.Unimplemented, "method "+r.Method+" not allowed"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				writeError(w, http.StatusRequestEntityTooLarge, status.New(codes.ResourceExhausted, err.Error()))
+				return
+			}
+			writeError(w, http.StatusBadRequest, status.New(codes.InvalidArgument, err.Error()))
+			return
+		}
+		req := PReq(new(Req))
+		if len(body) > 0 {
+			if err := protojson.Unmarshal(body, req); err != nil {
+				writeError(w, http.StatusBadRequest, status.New(codes.InvalidArgument, "parse request: "+err.Error()))
+				return
+			}
+		}
+
+		resp, err := handler(incomingContext(r), req)
+		if err != nil {
+			st := status.Convert(err)
+			setRetryAfter(w, st)
+			writeError(w, HTTPStatus(st.Code()), st)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp.(proto.Message))
+	})
+}
+
+// incomingContext returns the context of r carrying its headers as incoming
+// metadata and its client address as peer, like the context of a gRPC request.
+func incomingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for k, v := range r.Header {
+		md.Append(strings.ToLower(k), v...)
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	return ctx
+}
+
+// setRetryAfter sets the Retry-After header if st tells when to retry.
+func setRetryAfter(w http.ResponseWriter, st *status.Status) {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			seconds := math.Ceil(info.GetRetryDelay().AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+			return
+		}
+	}
+}
+
+// writeError writes st as JSON error body with the HTTP status code.
+func writeError(w http.ResponseWriter, code int, st *status.Status) {
+	writeJSON(w, code, st.Proto())
+}
+
+// writeJSON writes m as JSON body with the HTTP status code.
+func writeJSON(w http.ResponseWriter, code int, m proto.Message) {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(b)
+}
+
+// HTTPStatus returns the HTTP status code corresponding to the gRPC status
+// code c, following google.rpc.Code.
+func HTTPStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		// Client Closed Request, as used by nginx and grpc-gateway.
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
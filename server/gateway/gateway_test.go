@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+type fakeServer struct {
+	pb.UnimplementedCharacterCounterServer
+}
+
+func (fakeServer) CountCharacters(_ context.Context, req *pb.CountCharactersRequest) (*pb.CountCharactersResponse, error) {
+	if req.GetMode() == pb.CountingMode_COUNTING_MODE_BYTES {
+		return nil, status.Error(codes.InvalidArgument, "bytes not supported")
+	}
+	return &pb.CountCharactersResponse{Characters: uint64(len(req.GetText()))}, nil
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(fakeServer{})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "count", method: http.MethodPost, path: "/v1/count", body: `{"text": "abc"}`,
+			wantCode: http.StatusOK, wantBody: `"characters":"3"`},
+		{name: "rpc error", method: http.MethodPost, path: "/v1/count", body: `{"text": "abc", "mode": "COUNTING_MODE_BYTES"}`,
+			wantCode: http.StatusBadRequest, wantBody: `"message":"bytes not supported"`},
+		{name: "invalid json", method: http.MethodPost, path: "/v1/count", body: `{"text": 1}`,
+			wantCode: http.StatusBadRequest, wantBody: `"code":3`},
+		{name: "wrong method", method: http.MethodGet, path: "/v1/count",
+			wantCode: http.StatusMethodNotAllowed},
+		{name: "unimplemented", method: http.MethodPost, path: "/v1/text", body: `{}`,
+			wantCode: http.StatusNotImplemented},
+		{name: "unknown path", method: http.MethodPost, path: "/v1/unknown",
+			wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantCode)
+		}
+		// protojson randomly adds spaces to its output.
+		if body := rec.Body.String(); !strings.Contains(strings.ReplaceAll(body, " ", ""), strings.ReplaceAll(tt.wantBody, " ", "")) {
+			t.Errorf("%s: body = %s, want it to contain %s", tt.name, body, tt.wantBody)
+		}
+	}
+}
+
+func TestHandlerInterceptors(t *testing.T) {
+	var gotMethod, gotKey string
+	interceptor := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		gotMethod = info.FullMethod
+		md, _ := metadata.FromIncomingContext(ctx)
+		if keys := md.Get("x-api-key"); len(keys) > 0 {
+			gotKey = keys[0]
+		}
+		if gotKey == "limited" {
+			st, _ := status.New(codes.ResourceExhausted, "rate limit exceeded").
+				WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)})
+			return nil, st.Err()
+		}
+		return handler(ctx, req)
+	}
+	h := NewHandler(fakeServer{}, interceptor)
+
+	for _, key := range []string{"allowed", "limited"} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/count", strings.NewReader(`{"text": "abc"}`))
+		req.Header.Set("X-Api-Key", key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if gotMethod != "/frontend.CharacterCounter/CountCharacters" || gotKey != key {
+			t.Errorf("interceptor got method %q and key %q, want CountCharacters and %q", gotMethod, gotKey, key)
+		}
+		wantCode, wantRetryAfter := http.StatusOK, ""
+		if key == "limited" {
+			wantCode, wantRetryAfter = http.StatusTooManyRequests, "2"
+		}
+		if rec.Code != wantCode || rec.Header().Get("Retry-After") != wantRetryAfter {
+			t.Errorf("%s: status = %d, Retry-After = %q, want %d and %q",
+				key, rec.Code, rec.Header().Get("Retry-After"), wantCode, wantRetryAfter)
+		}
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := map[codes.Code]int{
+		codes.OK:                http.StatusOK,
+		codes.InvalidArgument:   http.StatusBadRequest,
+		codes.Unauthenticated:   http.StatusUnauthorized,
+		codes.ResourceExhausted: http.StatusTooManyRequests,
+		codes.Unavailable:       http.StatusServiceUnavailable,
+		codes.Internal:          http.StatusInternalServerError,
+	}
+	for c, want := range tests {
+		if got := HTTPStatus(c); got != want {
+			t.Errorf("HTTPStatus(%s) = %d, want %d", c, got, want)
+		}
+	}
+}
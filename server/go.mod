@@ -0,0 +1,28 @@
+module github.com/jonas27/ramp-up-k8s-operator/server
+
+go 1.20
+
+require (
+	github.com/jonas27/ramp-up-k8s-operator/proto v0.0.0
+	github.com/prometheus/client_golang v1.15.1
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/text v0.9.0
+	golang.org/x/time v0.3.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230525234030-28d5490b6b19
+	google.golang.org/grpc v1.57.0
+	google.golang.org/protobuf v1.31.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.4 // indirect
+	github.com/prometheus/client_model v0.3.0 // indirect
+	github.com/prometheus/common v0.42.0 // indirect
+	github.com/prometheus/procfs v0.9.0 // indirect
+	golang.org/x/net v0.9.0 // indirect
+	golang.org/x/sys v0.7.0 // indirect
+)
+
+replace github.com/jonas27/ramp-up-k8s-operator/proto => ../proto
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+// servicePrefix is the prefix of the full method names of the observed service.
+var servicePrefix = "/" + pb.CharacterCounter_ServiceDesc.ServiceName + "/"
+
+// sizeBuckets are the buckets of the payload size histograms, from 64 bytes
+// to 16 MiB.
+var sizeBuckets = prometheus.ExponentialBuckets(64, 4, 10)
+
+var (
+	// Requests counts the handled requests by method, counting mode and
+	// status code.
+	Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "character_counter_requests_total",
+		Help: "Number of handled CharacterCounter requests.",
+	}, []string{"method", "mode", "code"})
+
+	// RequestDuration observes how long requests took to handle.
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "character_counter_request_duration_seconds",
+		Help:    "Time taken to handle a CharacterCounter request.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+	}, []string{"method", "mode", "code"})
+
+	// RequestSize observes the size of the received messages of a request.
+	RequestSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "character_counter_request_size_bytes",
+		Help:    "Size of the protobuf encoded messages received with a CharacterCounter request.",
+		Buckets: sizeBuckets,
+	}, []string{"method", "mode", "code"})
+
+	// ResponseSize observes the size of the sent messages of a request.
+	ResponseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "character_counter_response_size_bytes",
+		Help:    "Size of the protobuf encoded messages sent in response to a CharacterCounter request.",
+		Buckets: sizeBuckets,
+	}, []string{"method", "mode", "code"})
+
+	// Characters counts the characters counted in successful responses.
+	Characters = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "character_counter_characters_total",
+		Help: "Number of characters counted, in the unit of the counting mode.",
+	}, []string{"method", "mode"})
+)
+
+func init() {
+	Registry.MustRegister(Requests, RequestDuration, RequestSize, ResponseSize, Characters)
+}
+
+// UnaryInterceptor returns an interceptor observing unary requests. It
+// should be the first interceptor, so rejected requests are observed too.
+func UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		r := request{method: info.FullMethod, start: start, reqSize: size(req), firstReq: req}
+		if err == nil {
+			r.resp, r.respSize = resp, size(resp)
+		}
+		r.observe(err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor returns an interceptor observing streams. It should be
+// the first interceptor, so rejected streams are observed too.
+func StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+			return handler(srv, ss)
+		}
+		s := &observedStream{ServerStream: ss, request: request{method: info.FullMethod, start: time.Now()}}
+		err := handler(srv, s)
+		s.observe(err)
+		return err
+	}
+}
+
+// request collects the observations of a single request.
+type request struct {
+	method            string
+	start             time.Time
+	reqSize, respSize int
+	firstReq, resp    any
+}
+
+// observe records r, which failed with err unless it is nil.
+func (r *request) observe(err error) {
+	method := strings.TrimPrefix(r.method, servicePrefix)
+	mode := modeLabel(r.resp, r.firstReq)
+	code := status.Code(err).String()
+
+	Requests.WithLabelValues(method, mode, code).Inc()
+	RequestDuration.WithLabelValues(method, mode, code).Observe(time.Since(r.start).Seconds())
+	RequestSize.WithLabelValues(method, mode, code).Observe(float64(r.reqSize))
+	ResponseSize.WithLabelValues(method, mode, code).Observe(float64(r.respSize))
+	if err != nil {
+		return
+	}
+
+	switch resp := r.resp.(type) {
+	case *pb.CountCharactersResponse:
+		addCharacters(method, resp)
+	case *pb.CountCharactersBatchResponse:
+		for _, result := range resp.GetResults() {
+			if resp := result.GetResponse(); resp != nil {
+				addCharacters(method, resp)
+			}
+		}
+	}
+}
+
+// addCharacters adds the characters counted in resp to the Characters of method.
+func addCharacters(method string, resp *pb.CountCharactersResponse) {
+	Characters.WithLabelValues(method, modeName(resp.GetMode())).Add(float64(resp.GetCharacters()))
+}
+
+// observedStream is a server stream recording the messages it passes.
+type observedStream struct {
+	grpc.ServerStream
+	request
+}
+
+func (s *observedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.firstReq == nil {
+		s.firstReq = m
+	}
+	s.reqSize += size(m)
+	return nil
+}
+
+func (s *observedStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.resp = m
+	s.respSize += size(m)
+	return nil
+}
+
+// modeLabel returns the counting mode label of the first of msgs having a
+// mode. Responses carry the mode actually counted in, so they should come
+// first. The label is empty if none has a mode, like batches, whose items
+// can be counted in different modes.
+func modeLabel(msgs ...any) string {
+	for _, m := range msgs {
+		if m, ok := m.(interface{ GetMode() pb.CountingMode }); ok {
+			return modeName(m.GetMode())
+		}
+	}
+	return ""
+}
+
+// modeName returns the label of mode, e.g. "code_points" for
+// COUNTING_MODE_CODE_POINTS. Unknown modes sent by clients share the label
+// "unknown" to bound the number of series.
+func modeName(mode pb.CountingMode) string {
+	name, ok := pb.CountingMode_name[int32(mode)]
+	if !ok {
+		return "unknown"
+	}
+	return strings.ToLower(strings.TrimPrefix(name, "COUNTING_MODE_"))
+}
+
+// size returns the size of the protobuf encoding of m, or 0 if m is no
+// protobuf message.
+func size(m any) int {
+	if m, ok := m.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
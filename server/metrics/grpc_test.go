@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+)
+
+// scrape returns the metrics served by Handler.
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	b, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	interceptor := UnaryInterceptor()
+	call := func(method string, req any, resp any, err error) {
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		_, _ = interceptor(context.Background(), req, info, func(context.Context, any) (any, error) {
+			return resp, err
+		})
+	}
+
+	call("/frontend.CharacterCounter/CountCharacters", &pb.CountCharactersRequest{Text: "héllo"},
+		&pb.CountCharactersResponse{Characters: 5, Mode: pb.CountingMode_COUNTING_MODE_CODE_POINTS}, nil)
+	call("/frontend.CharacterCounter/CountCharacters", &pb.CountCharactersRequest{Mode: 42},
+		(*pb.CountCharactersResponse)(nil), status.Error(codes.InvalidArgument, "invalid mode"))
+	call("/frontend.CharacterCounter/CountCharactersBatch", &pb.CountCharactersBatchRequest{},
+		&pb.CountCharactersBatchResponse{Results: []*pb.CountCharactersBatchResult{
+			{Result: &pb.CountCharactersBatchResult_Response{Response: &pb.CountCharactersResponse{
+				Characters: 3, Mode: pb.CountingMode_COUNTING_MODE_BYTES,
+			}}},
+			{Result: &pb.CountCharactersBatchResult_Error{Error: &pb.CountError{Code: int32(codes.InvalidArgument)}}},
+		}}, nil)
+	call("/grpc.health.v1.Health/Check", nil, nil, nil)
+
+	got := scrape(t)
+	for _, want := range []string{
+		`character_counter_requests_total{code="OK",method="CountCharacters",mode="code_points"} 1`,
+		`character_counter_requests_total{code="InvalidArgument",method="CountCharacters",mode="unknown"} 1`,
+		`character_counter_requests_total{code="OK",method="CountCharactersBatch",mode=""} 1`,
+		`character_counter_characters_total{method="CountCharacters",mode="code_points"} 5`,
+		`character_counter_characters_total{method="CountCharactersBatch",mode="bytes"} 3`,
+		`character_counter_request_size_bytes_count{code="OK",method="CountCharacters",mode="code_points"} 1`,
+		`character_counter_response_size_bytes_sum{code="InvalidArgument",method="CountCharacters",mode="unknown"} 0`,
+		`character_counter_request_duration_seconds_count{code="OK",method="CountCharacters",mode="code_points"} 1`,
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	if strings.Contains(got, `method="Check"`) {
+		t.Error("health checks are observed")
+	}
+}
+
+// fakeStream is a client streaming server stream receiving chunks.
+type fakeStream struct {
+	grpc.ServerStream
+	chunks []*pb.CountCharactersChunk
+}
+
+func (s *fakeStream) Context() context.Context { return context.Background() }
+
+func (s *fakeStream) RecvMsg(m any) error {
+	if len(s.chunks) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(*pb.CountCharactersChunk), s.chunks[0])
+	s.chunks = s.chunks[1:]
+	return nil
+}
+
+func (s *fakeStream) SendMsg(any) error { return nil }
+
+func TestStreamInterceptor(t *testing.T) {
+	ss := &fakeStream{chunks: []*pb.CountCharactersChunk{
+		{Data: []byte("abc"), Mode: pb.CountingMode_COUNTING_MODE_GRAPHEMES},
+		{Data: []byte("de")},
+	}}
+	info := &grpc.StreamServerInfo{FullMethod: "/frontend.CharacterCounter/CountCharactersStream", IsClientStream: true}
+	err := StreamInterceptor()(nil, ss, info, func(_ any, stream grpc.ServerStream) error {
+		for {
+			if err := stream.RecvMsg(&pb.CountCharactersChunk{}); err == io.EOF {
+				break
+			}
+		}
+		return stream.SendMsg(&pb.CountCharactersResponse{Characters: 5, Mode: pb.CountingMode_COUNTING_MODE_GRAPHEMES})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := scrape(t)
+	for _, want := range []string{
+		`character_counter_requests_total{code="OK",method="CountCharactersStream",mode="graphemes"} 1`,
+		`character_counter_characters_total{method="CountCharactersStream",mode="graphemes"} 5`,
+		// Both chunks: 3+2 data bytes, 2+2 field overhead and 2 for the mode.
+		`character_counter_request_size_bytes_sum{code="OK",method="CountCharactersStream",mode="graphemes"} 11`,
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
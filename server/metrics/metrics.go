@@ -0,0 +1,49 @@
+// Package metrics defines the Prometheus metrics of the character counter
+// server and serves them.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds all metrics of the server.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// ConfigInfo has a single series set to 1, labeled with the revision of
+	// the configuration the server currently uses.
+	ConfigInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "character_counter_config_info",
+		Help: "Revision of the loaded configuration file, always 1.",
+	}, []string{"revision"})
+
+	// ConfigReloads counts the configuration changes applied without a restart.
+	ConfigReloads = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "character_counter_config_reloads_total",
+		Help: "Number of configuration changes applied without a restart.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ConfigInfo,
+		ConfigReloads,
+	)
+}
+
+// SetConfigRevision records revision as the revision of the used configuration.
+func SetConfigRevision(revision string) {
+	ConfigInfo.Reset()
+	ConfigInfo.WithLabelValues(revision).Set(1)
+}
+
+// Handler returns the handler serving all metrics of Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
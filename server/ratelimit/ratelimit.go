@@ -0,0 +1,232 @@
+// Package ratelimit limits the request rate of clients of the character
+// counter service with token buckets.
+//
+// Every client gets its own bucket, identified by its address or by its API
+// key. Behind the auth interceptor, the API key of a client is the name of
+// the key it authenticated with. Otherwise it is the one the client sends in
+// the request metadata, and as clients choose it themselves, their requests
+// take a token from the bucket of their address as well. A request takes a
+// token, a stream takes a single token when it starts. Requests finding a
+// bucket empty fail with ResourceExhausted and a google.rpc.RetryInfo detail
+// telling when to retry. Only the frontend.CharacterCounter service is
+// limited, health checks and reflection are always served.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "github.com/jonas27/ramp-up-k8s-operator/proto"
+	"github.com/jonas27/ramp-up-k8s-operator/server/auth"
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+// RetryAfterHeader is the response header holding the number of seconds a
+// rejected client should wait before retrying.
+const RetryAfterHeader = "retry-after"
+
+// sweepInterval is how often buckets of clients that have not sent requests
+// for a while are removed.
+const sweepInterval = time.Minute
+
+// maxAPIKeyBuckets is the maximum number of buckets of unauthenticated API
+// keys. Clients sending new keys beyond it are only limited by address, so
+// the buckets do not grow without bound.
+const maxAPIKeyBuckets = 10000
+
+// apiKeyPrefix is the prefix of the bucket keys of unauthenticated API keys.
+const apiKeyPrefix = "apiKey:"
+
+// bucket is the token bucket of a client.
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter limits the request rate of clients. The zero value is not usable,
+// create a Limiter with New.
+type Limiter struct {
+	mu        sync.Mutex
+	cfg       config.RateLimit
+	buckets   map[string]*bucket
+	apiKeys   int
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New returns a Limiter applying cfg.
+func New(cfg config.RateLimit) *Limiter {
+	return &Limiter{cfg: cfg, buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// SetConfig replaces the limits of l with cfg. If they changed, all clients
+// start with a full bucket.
+func (l *Limiter) SetConfig(cfg config.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cfg != l.cfg {
+		l.cfg = cfg
+		l.buckets, l.apiKeys = map[string]*bucket{}, 0
+	}
+}
+
+// UnaryInterceptor returns an interceptor rejecting unary requests of clients
+// exceeding their limit. It should run after the auth interceptor, if any.
+func (l *Limiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor returns an interceptor rejecting streams of clients
+// exceeding their limit. It should run after the auth interceptor, if any.
+func (l *Limiter) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// check takes a token from the buckets of the client of ctx if method is
+// limited. It returns a ResourceExhausted error if a bucket is empty.
+func (l *Limiter) check(ctx context.Context, method string) error {
+	if !strings.HasPrefix(method, "/"+pb.CharacterCounter_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+
+	l.mu.Lock()
+	cfg := l.cfg
+	if !cfg.Enabled() {
+		l.mu.Unlock()
+		return nil
+	}
+	delay := l.take(l.clientKeys(ctx, cfg))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	seconds := int(math.Ceil(delay.Seconds()))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.Itoa(seconds)))
+	description := fmt.Sprintf("more than %g requests per second", cfg.RequestsPerSecond)
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded: "+description)
+	if withDetails, err := st.WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)},
+		&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{Subject: cfg.Key, Description: description}},
+		},
+	); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// take takes a token from the buckets of keys and returns how long the client
+// has to wait for the next token if any of them is empty, in which case no
+// token is taken. l.mu must be held.
+func (l *Limiter) take(keys []string) time.Duration {
+	now := l.now()
+	l.sweep(now)
+
+	var delay time.Duration
+	reservations := make([]*rate.Reservation, 0, len(keys))
+	for _, key := range keys {
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &bucket{limiter: rate.NewLimiter(rate.Limit(l.cfg.RequestsPerSecond), l.cfg.Burst)}
+			l.buckets[key] = b
+			if strings.HasPrefix(key, apiKeyPrefix) {
+				l.apiKeys++
+			}
+		}
+		b.lastSeen = now
+
+		r := b.limiter.ReserveN(now, 1)
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > delay {
+			delay = d
+		}
+	}
+	if delay > 0 {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+	return delay
+}
+
+// sweep removes the buckets that have been refilled completely since their
+// client sent its last request, as they behave like new ones. l.mu must be
+// held.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(float64(l.cfg.Burst) / l.cfg.RequestsPerSecond * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > refill {
+			delete(l.buckets, key)
+			if strings.HasPrefix(key, apiKeyPrefix) {
+				l.apiKeys--
+			}
+		}
+	}
+}
+
+// clientKeys returns the keys of the buckets of the client of ctx. l.mu must
+// be held. If cfg says so, clients are identified by the name of the key they
+// authenticated with. Otherwise they are identified by the host of their
+// address and, if cfg says so, by the API key they sent, unless it has no
+// bucket yet and there are maxAPIKeyBuckets already. As unauthenticated
+// clients choose their API key themselves, they cannot evade the limit of
+// their address by rotating keys.
+func (l *Limiter) clientKeys(ctx context.Context, cfg config.RateLimit) []string {
+	if cfg.Key != config.RateLimitKeyAPIKey {
+		return []string{peerKey(ctx)}
+	}
+	if name, ok := auth.ClientFromContext(ctx); ok {
+		return []string{"client:" + name}
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get(cfg.APIKeyHeader); len(keys) > 0 && keys[0] != "" {
+		key := apiKeyPrefix + keys[0]
+		if _, ok := l.buckets[key]; ok || l.apiKeys < maxAPIKeyBuckets {
+			return []string{key, peerKey(ctx)}
+		}
+	}
+	return []string{peerKey(ctx)}
+}
+
+// peerKey returns the bucket key of the host of the address of the client of ctx.
+func peerKey(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "peer:unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "peer:" + host
+}
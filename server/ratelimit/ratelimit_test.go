@@ -0,0 +1,201 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/jonas27/ramp-up-k8s-operator/server/auth"
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+const countMethod = "/frontend.CharacterCounter/CountCharacters"
+
+func peerContext(addr string) context.Context {
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		panic(err)
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr})
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New(config.RateLimit{RequestsPerSecond: 2, Burst: 2, Key: config.RateLimitKeyPeer})
+	l.now = func() time.Time { return now }
+
+	client := peerContext("10.0.0.1:1234")
+	sameHost := peerContext("10.0.0.1:5678")
+	other := peerContext("10.0.0.2:1234")
+
+	for i := 0; i < 2; i++ {
+		if err := l.check(client, countMethod); err != nil {
+			t.Fatalf("request %d: %v, want nil", i, err)
+		}
+	}
+	err := l.check(sameHost, countMethod)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("request over burst = %v, want ResourceExhausted", err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if got := retry.GetRetryDelay().AsDuration(); got != 500*time.Millisecond {
+		t.Errorf("retry delay = %s, want 500ms", got)
+	}
+
+	if err := l.check(other, countMethod); err != nil {
+		t.Errorf("request of other client = %v, want nil", err)
+	}
+	if err := l.check(client, "/grpc.health.v1.Health/Check"); err != nil {
+		t.Errorf("health check = %v, want nil", err)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if err := l.check(client, countMethod); err != nil {
+		t.Errorf("request after refill = %v, want nil", err)
+	}
+}
+
+func TestLimiterAPIKey(t *testing.T) {
+	l := New(config.RateLimit{RequestsPerSecond: 1, Burst: 1, Key: config.RateLimitKeyAPIKey, APIKeyHeader: "x-api-key"})
+	l.now = func() time.Time { return time.Unix(0, 0) }
+
+	withKey := func(addr, key string) context.Context {
+		return metadata.NewIncomingContext(peerContext(addr), metadata.Pairs("x-api-key", key))
+	}
+	if err := l.check(withKey("10.0.0.1:1234", "a"), countMethod); err != nil {
+		t.Fatalf("request with key a = %v, want nil", err)
+	}
+	if err := l.check(withKey("10.0.0.2:1234", "b"), countMethod); err != nil {
+		t.Errorf("request with key b = %v, want nil", err)
+	}
+	if err := l.check(withKey("10.0.0.3:1234", "a"), countMethod); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second request with key a = %v, want ResourceExhausted", err)
+	}
+	if err := l.check(peerContext("10.0.0.3:1234"), countMethod); err != nil {
+		t.Errorf("request without key = %v, want nil", err)
+	}
+}
+
+func TestLimiterRotatedAPIKeys(t *testing.T) {
+	l := New(config.RateLimit{RequestsPerSecond: 1, Burst: 1, Key: config.RateLimitKeyAPIKey, APIKeyHeader: "x-api-key"})
+	l.now = func() time.Time { return time.Unix(0, 0) }
+
+	withKey := func(key string) context.Context {
+		return metadata.NewIncomingContext(peerContext("10.0.0.1:1234"), metadata.Pairs("x-api-key", key))
+	}
+	if err := l.check(withKey("0"), countMethod); err != nil {
+		t.Fatalf("first request = %v, want nil", err)
+	}
+	for i := 1; i < 10; i++ {
+		if err := l.check(withKey(strconv.Itoa(i)), countMethod); status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("request with rotated key %d = %v, want ResourceExhausted", i, err)
+		}
+	}
+}
+
+func TestLimiterAuthenticatedAPIKey(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "alice"), []byte("key-a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a := auth.New(config.Auth{KeysDir: dir})
+	l := New(config.RateLimit{RequestsPerSecond: 1, Burst: 1, Key: config.RateLimitKeyAPIKey, APIKeyHeader: "x-api-key"})
+	l.now = func() time.Time { return time.Unix(0, 0) }
+
+	// check authenticates a request of alice sending apiKey, like the
+	// interceptor chain of the server.
+	check := func(apiKey string) error {
+		ctx := metadata.NewIncomingContext(peerContext("10.0.0.1:1234"),
+			metadata.Pairs("authorization", "Bearer key-a", "x-api-key", apiKey))
+		info := &grpc.UnaryServerInfo{FullMethod: countMethod}
+		_, err := a.UnaryInterceptor()(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+			return nil, l.check(ctx, countMethod)
+		})
+		return err
+	}
+	if err := check("a"); err != nil {
+		t.Fatalf("first request = %v, want nil", err)
+	}
+	if err := check("b"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("request with another API key = %v, want ResourceExhausted", err)
+	}
+}
+
+func TestLimiterAPIKeyBucketCap(t *testing.T) {
+	l := New(config.RateLimit{RequestsPerSecond: 1, Burst: 1, Key: config.RateLimitKeyAPIKey, APIKeyHeader: "x-api-key"})
+	l.now = func() time.Time { return time.Unix(0, 0) }
+	withKey := func(addr, key string) context.Context {
+		return metadata.NewIncomingContext(peerContext(addr), metadata.Pairs("x-api-key", key))
+	}
+	for i := 0; i < maxAPIKeyBuckets; i++ {
+		addr := net.JoinHostPort(net.IPv4(10, 1, byte(i>>8), byte(i)).String(), "1234")
+		if err := l.check(withKey(addr, strconv.Itoa(i)), countMethod); err != nil {
+			t.Fatalf("request with key %d = %v, want nil", i, err)
+		}
+	}
+
+	if err := l.check(withKey("10.0.0.2:1234", "new-1"), countMethod); err != nil {
+		t.Fatalf("first request with a new key = %v, want nil", err)
+	}
+	if _, ok := l.buckets[apiKeyPrefix+"new-1"]; ok {
+		t.Errorf("new key got a bucket beyond %d keys", maxAPIKeyBuckets)
+	}
+	if err := l.check(withKey("10.0.0.2:1234", "new-2"), countMethod); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second request with a new key from the same address = %v, want ResourceExhausted", err)
+	}
+	if err := l.check(withKey("10.0.0.3:1234", "0"), countMethod); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("request with a known key = %v, want ResourceExhausted from its own bucket", err)
+	}
+	if got, want := len(l.buckets), 2*maxAPIKeyBuckets+2; got != want {
+		t.Errorf("buckets = %d, want %d", got, want)
+	}
+}
+
+func TestLimiterSetConfig(t *testing.T) {
+	l := New(config.RateLimit{})
+	l.now = func() time.Time { return time.Unix(0, 0) }
+	ctx := peerContext("10.0.0.1:1234")
+
+	for i := 0; i < 3; i++ {
+		if err := l.check(ctx, countMethod); err != nil {
+			t.Fatalf("request %d while disabled = %v, want nil", i, err)
+		}
+	}
+	l.SetConfig(config.RateLimit{RequestsPerSecond: 1, Burst: 1, Key: config.RateLimitKeyPeer})
+	if err := l.check(ctx, countMethod); err != nil {
+		t.Fatalf("first request after enabling = %v, want nil", err)
+	}
+	if err := l.check(ctx, countMethod); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second request after enabling = %v, want ResourceExhausted", err)
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	l := New(config.RateLimit{RequestsPerSecond: 1, Burst: 1, Key: config.RateLimitKeyPeer})
+	ctx := peerContext("10.0.0.1:1234")
+	info := &grpc.UnaryServerInfo{FullMethod: countMethod}
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+
+	if resp, err := l.UnaryInterceptor()(ctx, nil, info, handler); err != nil || resp != "ok" {
+		t.Fatalf("first request = %v, %v, want ok", resp, err)
+	}
+	if _, err := l.UnaryInterceptor()(ctx, nil, info, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second request = %v, want ResourceExhausted", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Package tlsconfig builds the TLS configuration of the character counter
+// server from certificate files.
+//
+// The files are checked for changes on every handshake and read again when
+// they changed, so certificates rotated by the operator are picked up
+// without a restart. Mounted Secrets are updated by swapping symlinks, which
+// changes the modification time of the files they point to.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+// Loader loads the TLS configuration from the files of a config.TLS.
+type Loader struct {
+	cfg config.TLS
+
+	mu       sync.Mutex
+	modTimes []time.Time
+	current  *tls.Config
+}
+
+// New returns a Loader for the files of cfg. It fails if they cannot be loaded.
+func New(cfg config.TLS) (*Loader, error) {
+	l := &Loader{cfg: cfg}
+	if _, err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Config returns a TLS server configuration negotiating nextProtos with ALPN,
+// which is read from the current content of the files on every handshake.
+func (l *Loader) Config(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg, err := l.load()
+			if err != nil {
+				return nil, err
+			}
+			cfg = cfg.Clone()
+			cfg.NextProtos = nextProtos
+			return cfg, nil
+		},
+	}
+}
+
+// load returns the configuration read from the files, reading them again if
+// they changed since the last call. If changed files cannot be loaded, e.g.
+// because only some of them were updated yet, the previous configuration is
+// kept.
+func (l *Loader) load() (*tls.Config, error) {
+	files := []string{l.cfg.CertFile, l.cfg.KeyFile}
+	if l.cfg.ClientAuth != config.ClientAuthNone {
+		files = append(files, l.cfg.ClientCAFile)
+	}
+	modTimes := make([]time.Time, len(files))
+	for i, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return l.fallback(fmt.Errorf("stat %s: %w", f, err))
+		}
+		modTimes[i] = fi.ModTime()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current != nil && equalTimes(modTimes, l.modTimes) {
+		return l.current, nil
+	}
+
+	cfg, err := read(l.cfg)
+	if err != nil {
+		if l.current != nil {
+			// Retry with the next change instead of on every handshake.
+			l.modTimes = modTimes
+			log.Printf("keeping previous TLS certificates: %v", err)
+			return l.current, nil
+		}
+		return nil, err
+	}
+	if l.current != nil {
+		log.Print("reloaded TLS certificates")
+	}
+	l.current, l.modTimes = cfg, modTimes
+	return cfg, nil
+}
+
+// fallback returns the current configuration, or err if there is none.
+func (l *Loader) fallback(err error) (*tls.Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current == nil {
+		return nil, err
+	}
+	return l.current, nil
+}
+
+// read reads the files of cfg into a TLS server configuration.
+func read(cfg config.TLS) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load serving certificate: %w", err)
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	switch cfg.ClientAuth {
+	case config.ClientAuthOptional:
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	case config.ClientAuthRequire:
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return tlsCfg, nil
+	}
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA file: %w", err)
+	}
+	tlsCfg.ClientCAs = x509.NewCertPool()
+	if !tlsCfg.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in client CA file %s", cfg.ClientCAFile)
+	}
+	return tlsCfg, nil
+}
+
+// equalTimes reports whether a and b hold the same times.
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
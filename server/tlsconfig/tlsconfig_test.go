@@ -0,0 +1,165 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonas27/ramp-up-k8s-operator/server/config"
+)
+
+// issue returns a certificate for name signed by parent, or self-signed if
+// parent is nil.
+func issue(t *testing.T, name string, serial int64, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, any(key)
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writeCert writes cert and its key as PEM files.
+func writeCert(t *testing.T, cert tls.Certificate, certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// handshake connects to lis with the client certificates and returns the
+// serving certificate.
+func handshake(lis net.Listener, roots *x509.CertPool, certs []tls.Certificate) (*x509.Certificate, error) {
+	conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{
+		RootCAs: roots, Certificates: certs, ServerName: "server", NextProtos: []string{"h2"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// The server verifies client certificates after the client finished
+	// the TLS 1.3 handshake, so read to learn about rejections.
+	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			return nil, err
+		}
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.TLS{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:   config.ClientAuthRequire,
+	}
+	ca := issue(t, "ca", 1, nil)
+	writeCert(t, ca, cfg.ClientCAFile, filepath.Join(dir, "ca.key"))
+	writeCert(t, issue(t, "server", 2, &ca), cfg.CertFile, cfg.KeyFile)
+	client := issue(t, "client", 3, &ca)
+
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", l.Config("h2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = conn.Read(make([]byte, 1))
+			}()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	cert, err := handshake(lis, roots, []tls.Certificate{client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.SerialNumber.Int64() != 2 {
+		t.Errorf("serving certificate serial = %d, want 2", cert.SerialNumber)
+	}
+	if _, err := handshake(lis, roots, nil); err == nil {
+		t.Error("handshake without client certificate succeeded")
+	}
+
+	// Rotate the serving certificate with a modification time the file
+	// system can tell apart.
+	writeCert(t, issue(t, "server", 4, &ca), cfg.CertFile, cfg.KeyFile)
+	later := time.Now().Add(time.Minute)
+	for _, f := range []string{cfg.CertFile, cfg.KeyFile} {
+		if err := os.Chtimes(f, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cert, err = handshake(lis, roots, []tls.Certificate{client}); err != nil {
+		t.Fatal(err)
+	}
+	if cert.SerialNumber.Int64() != 4 {
+		t.Errorf("serving certificate serial after rotation = %d, want 4", cert.SerialNumber)
+	}
+}
+
+func TestNewInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New(config.TLS{
+		CertFile:   filepath.Join(dir, "missing.crt"),
+		KeyFile:    filepath.Join(dir, "missing.key"),
+		ClientAuth: config.ClientAuthNone,
+	}); err == nil {
+		t.Error("New() accepted missing certificate files")
+	}
+}